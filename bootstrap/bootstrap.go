@@ -1,59 +1,135 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
 
 	"gihutb.com/jxmoore/hubbub/helpers"
 	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
 
 	"gihutb.com/jxmoore/hubbub/watcher"
 )
 
 // BootStrap is the init function for the project, it is responsible for parsing the config,
 // setting the handler, getting credentials and initiating the watch processes.
-// Its exported as its called by Main.
-func BootStrap(path string, envOnly bool) error {
+// Its exported as its called by Main. kubeConfig and kubeContext are passed through to helpers.GetKubeClient() and may be empty.
+// If configMap (namespace/name) is set the config is read from the key named after the file in path in that ConfigMap instead of
+// the file itself. Unless envOnly is set the config is watched for changes, the watchers are restarted with each valid update.
+// If apiAddress is set the silence API is served on it and requires apiToken, the silences created through it are kept across config
+// changes, as is the state of the watchers (the dedup cache, incidents and digest counts).
+// BootStrap returns nil once ctx is done and the watchers have stopped, Main cancels ctx when Hubbub receives a SIGTERM.
+func BootStrap(ctx context.Context, path, configMap string, envOnly bool, kubeConfig, kubeContext, apiAddress, apiToken string) error {
 
-	// load and parse inital config
 	fmt.Printf("Starting Hubbub...\n")
-	config := &models.Config{}
 
+	// pull kubernetes clientinfo, either from a kubeconfig or incluster
+	client, err := helpers.GetKubeClient(kubeConfig, kubeContext)
+	if err != nil {
+		return fmt.Errorf("error getting kubeclient info : \n%v", err.Error())
+	}
+
+	// load and parse inital config
+	var source configSource
+	var content []byte
 	if !envOnly {
-		if err := config.Load(path); err != nil {
+
+		if configMap != "" {
+			source, err = configMapSource(client, configMap, filepath.Base(path))
+			if err != nil {
+				return fmt.Errorf("error loading config : \n%v", err)
+			}
+		} else {
+			source = fileSource(path)
+		}
+
+		if content, err = source(); err != nil {
 			return fmt.Errorf("error loading config : \n%v", err)
 		}
 	}
 
-	config.LoadEnvVars()
+	current, err := loadConfig(content)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// I beleive that a NULL Namespace in Pods().Watch() will watch everything but because i havent tested it we just enforce it.
-	if config.Namespace == "" {
-		return fmt.Errorf("please ensure the config has a Namespace specified")
+	updates := make(chan configUpdate)
+	if source != nil {
+		go watchConfig(ctx, source, content, configPollInterval, updates)
 	}
 
-	helpers.DebugLog(config.Debug, "Configuration loaded...", config)
+	silences := silence.NewRegistry()
+	if apiAddress != "" {
+		go func() {
+			if err := silence.Serve(ctx, apiAddress, apiToken, silences); err != nil {
+				fmt.Println(err.Error()) // non termintating, the silences in the config still apply
+			}
+		}()
+	}
 
-	// Setup the notifications interface
-	var handler models.NotificationHandler
-	if config.Notification.Handler == "slack" || config.Notification.Handler == "sl" {
-		handler = new(models.Slack)
-	} else if config.Notification.Handler == "appinsights" || config.Notification.Handler == "ai" || config.Notification.Handler == "applicationinsights" {
-		handler = new(models.ApplicationInsights)
-	} else {
-		handler = new(models.STDOUT)
+	// like the silences the state of the watchers is kept across config changes and terms as the leader
+	state := watcher.NewState(client, silences)
+
+	return run(ctx, current, updates, func(ctx context.Context, config *models.Config, handler models.NotificationHandler) error {
+
+		silences.SetConfigured(config.Silences)
+
+		// with leader election on only the replica holding the lease watches the cluster
+		if config.LeaderElection.Enabled {
+			return watcher.StartLeaderElection(ctx, client, config, handler, state)
+		}
+
+		return watcher.StartWatcher(ctx, client, config, handler, state)
+	})
+}
+
+// loadConfig builds a config from the json in content and the env variables, validates it and initializes the handler
+// it names. content is nil when only the env variables are used.
+func loadConfig(content []byte) (configUpdate, error) {
+
+	config := &models.Config{}
+	if content != nil {
+		if err := config.Parse(content); err != nil {
+			return configUpdate{}, fmt.Errorf("error loading config : \n%v", err)
+		}
+	}
+
+	if err := config.LoadEnvVars(); err != nil {
+		return configUpdate{}, fmt.Errorf("error loading config : \n%v", err)
 	}
 
-	if err := handler.Init(config); err != nil {
-		return fmt.Errorf("error prepaing handler interface : \n%v", err.Error())
+	// An empty namespace would result in a cluster wide watch, thats only allowed when explicitly asked for via AllNamespaces.
+	if err := config.Validate(); err != nil {
+		return configUpdate{}, err
 	}
 
-	// pull kubernetes incluster clientinfo
-	client, err := helpers.GetKubeClient()
+	helpers.DebugLog(config.Debug, "Configuration loaded...", config)
+
+	// Setup the notifications interface
+	handler, err := helpers.NewHandler(config)
 	if err != nil {
-		return fmt.Errorf("error getting kubeclient info : \n%v", err.Error())
+		return configUpdate{}, fmt.Errorf("error prepaing handler interface : \n%v", err.Error())
 	}
 
-	watcher.StartWatcher(client, config, handler)
+	// and the handlers the incidents are escalated through
+	for _, policy := range config.Escalations {
+		for i := range policy.Steps {
+			if policy.Steps[i].Handler, err = helpers.NewHandler(config.HandlerConfig(policy.Steps[i].Notification)); err != nil {
+				return configUpdate{}, fmt.Errorf("error prepaing the handler for step %v of the escalation policy %v : \n%v", i+1, policy.Name, err.Error())
+			}
+		}
+	}
+
+	// the digest uses its own handler when it names one
+	if config.Digest.Period != "" && config.Digest.Notification.Handler != "" {
+		if config.Digest.Handler, err = helpers.NewHandler(config.HandlerConfig(config.Digest.Notification)); err != nil {
+			return configUpdate{}, fmt.Errorf("error prepaing the handler for the digest : \n%v", err.Error())
+		}
+	}
 
-	return nil
+	return configUpdate{config: config, handler: handler}, nil
 }
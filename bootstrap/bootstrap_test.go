@@ -1,7 +1,12 @@
 package bootstrap
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -13,12 +18,44 @@ var testConfigFile = models.Config{
 	Namespace: "jomo",
 }
 
-// TestBootStrap tests the BootStrap() function that acts as Hubbubs init. Due to the way the KubeConfig is pulled currently it will error out when pulling the credentials hence the knownIssue variable.
-// This should change in the very near future.
+// testKubeConfig is a kubeconfig template, the server is filled in with the address of the fake API server.
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: hubbub
+  cluster:
+    server: %v
+contexts:
+- name: hubbub
+  context:
+    cluster: hubbub
+    user: hubbub
+current-context: hubbub
+users:
+- name: hubbub
+  user:
+    token: hubbub
+`
+
+// TestBootStrap tests the BootStrap() function that acts as Hubbubs init. A kubeconfig pointing at a fake API server
+// is passed in, the fake server refuses every request so BootStrap() should return once the watcher fails to start.
 func TestBootStrap(t *testing.T) {
 
-	// Currently no way of testing locally with kubeconfig so this will fail with the following :
-	knownIssue := "Can not get kubernetes config: unable to load in-cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined"
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"hubbub is forbidden","reason":"Forbidden","code":403}`)
+	}))
+	defer apiServer.Close()
+
+	kubeConfigPath := "./testKubeConfig"
+	if err := ioutil.WriteFile(kubeConfigPath, []byte(fmt.Sprintf(testKubeConfig, apiServer.URL)), 0666); err != nil {
+		t.Fatalf("Error writing the kubeconfig %v", err.Error())
+	}
+	defer os.Remove(kubeConfigPath)
+
+	// The fake API server returns a 403 for everything so the watch can not be created
+	forbidden := "error creating watcher : hubbub is forbidden"
 
 	// Our env variables that will be passed in
 	envVariables := map[string]string{
@@ -43,6 +80,8 @@ func TestBootStrap(t *testing.T) {
 		// Use only env variables (passed to BootStrap()) and the map of the values
 		useEnv bool
 		envVar map[string]string
+		// The kubeconfig passed to BootStrap()
+		kubeConfig string
 		// The expected error response as a string (err.Error())
 		errorResponse string
 		// Clean up the configs after the test
@@ -58,7 +97,8 @@ func TestBootStrap(t *testing.T) {
 			Self:          "Hubbub",
 			clean:         true,
 			useEnv:        false,
-			errorResponse: knownIssue,
+			kubeConfig:    kubeConfigPath,
+			errorResponse: forbidden,
 		},
 		"Create a config with Secret namespace and pass it into BootStrap": {
 			namespace:     "Secret",
@@ -70,7 +110,8 @@ func TestBootStrap(t *testing.T) {
 			filePath:      "./testConf2.json",
 			clean:         true,
 			useEnv:        false,
-			errorResponse: knownIssue,
+			kubeConfig:    kubeConfigPath,
+			errorResponse: forbidden,
 		},
 		"Create a config with testo namespace and pass it into BootStrap": {
 			namespace:     "testo",
@@ -82,13 +123,22 @@ func TestBootStrap(t *testing.T) {
 			filePath:      "./testConf3.json",
 			clean:         true,
 			useEnv:        false,
-			errorResponse: knownIssue,
+			kubeConfig:    kubeConfigPath,
+			errorResponse: forbidden,
 		},
 		"Use Env Variables": {
 			filePath:      "./noRealFile/Here.log",
 			useEnv:        true,
 			envVar:        envVariables,
-			errorResponse: knownIssue,
+			kubeConfig:    kubeConfigPath,
+			errorResponse: forbidden,
+		},
+		"A missing kubeconfig should be reported": {
+			filePath:      "./noRealFile/Here.log",
+			useEnv:        true,
+			envVar:        envVariables,
+			kubeConfig:    "./noRealFile/kubeconfig",
+			errorResponse: "error getting kubeclient info : \nerror getting config : stat ./noRealFile/kubeconfig: no such file or directory",
 		},
 	}
 
@@ -132,10 +182,12 @@ func TestBootStrap(t *testing.T) {
 
 		}
 
-		if err := BootStrap(testCase.filePath, testCase.useEnv); err != nil {
+		if err := BootStrap(context.Background(), testCase.filePath, "", testCase.useEnv, testCase.kubeConfig, "", "", ""); err != nil {
 			if err.Error() != testCase.errorResponse {
 				t.Errorf("Expected BootStrap to return the error %v\nReceived %v", testCase.errorResponse, err.Error())
 			}
+		} else {
+			t.Errorf("Expected BootStrap to return the error %v\nReceived nil", testCase.errorResponse)
 		}
 
 	}
@@ -0,0 +1,165 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// configPollInterval is how often the config is checked for changes. The kubelet only refreshes a mounted ConfigMap every
+// minute or so, polling works the same way for a file and for a ConfigMap read from the API.
+const configPollInterval = 10 * time.Second
+
+// configSource returns the raw json config.
+type configSource func() ([]byte, error)
+
+// configUpdate is a validated config and the handler that was initialized with it.
+type configUpdate struct {
+	config  *models.Config
+	handler models.NotificationHandler
+}
+
+// handlers returns the handler of the update followed by the handlers of the escalation steps and the digest in its config.
+func (u configUpdate) handlers() []models.NotificationHandler {
+
+	handlers := []models.NotificationHandler{u.handler}
+	for _, policy := range u.config.Escalations {
+		for _, step := range policy.Steps {
+			if step.Handler != nil {
+				handlers = append(handlers, step.Handler)
+			}
+		}
+	}
+
+	if u.config.Digest.Handler != nil {
+		handlers = append(handlers, u.config.Digest.Handler)
+	}
+
+	return handlers
+}
+
+// fileSource reads the config from a file, such as a ConfigMap mounted into the pod.
+func fileSource(path string) configSource {
+
+	return func() ([]byte, error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open config file %v.\n%v ", path, err)
+		}
+		return content, nil
+	}
+}
+
+// configMapSource reads the config from key in the ConfigMap configMap, which is in the form namespace/name.
+func configMapSource(kubeClient kubernetes.Interface, configMap, key string) (configSource, error) {
+
+	parts := strings.Split(configMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid ConfigMap %v, it should be in the form namespace/name", configMap)
+	}
+
+	return func() ([]byte, error) {
+		cm, err := kubeClient.CoreV1().ConfigMaps(parts[0]).Get(parts[1], meta_v1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the ConfigMap %v.\n%v ", configMap, err)
+		}
+
+		content, ok := cm.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("the ConfigMap %v has no key %v", configMap, key)
+		}
+		return []byte(content), nil
+	}, nil
+}
+
+// watchConfig reads source every interval until ctx is done. When the config has changed it is loaded and sent on updates,
+// an update that can not be read, parsed or validated is logged and the watchers keep running with the current config. An empty
+// config is never an update, the file is read while it is being written and Parse would accept it as an env only config.
+// last is the config that is currently in use.
+func watchConfig(ctx context.Context, source configSource, last []byte, interval time.Duration, updates chan<- configUpdate) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		content, err := source()
+		if err != nil {
+			fmt.Printf("Unable to check the config for changes : %v\n", err)
+			continue
+		}
+
+		if bytes.Equal(content, last) {
+			continue
+		}
+
+		// last is not changed, the config is compared with the one in use again on the next check
+		if len(content) == 0 {
+			fmt.Printf("The config is empty, keeping the current config\n")
+			continue
+		}
+
+		// the rejected config is kept in last so the error is only logged once for each change
+		last = content
+		update, err := loadConfig(content)
+		if err != nil {
+			fmt.Printf("Rejecting the updated config : %v\n", err)
+			continue
+		}
+
+		select {
+		case updates <- update:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run calls start with the current config and handler, each update received stops the watchers by cancelling their context and
+// starts them again with the new config, the old handlers are flushed once their watchers have stopped. When ctx is done the watchers
+// are given shutdownTimeout to finish the notifications they are sending before the handlers are flushed. It returns the error from
+// start, or nil once ctx is done.
+func run(ctx context.Context, current configUpdate, updates <-chan configUpdate, start func(ctx context.Context, config *models.Config, handler models.NotificationHandler) error) error {
+
+	for {
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func(current configUpdate) {
+			done <- start(watchCtx, current.config, current.handler)
+		}(current)
+
+		select {
+		case err := <-done:
+			cancel()
+			flush(current.handlers()...)
+			return err
+		case <-ctx.Done():
+			cancel()
+			drain(done, shutdownTimeout)
+			flush(current.handlers()...)
+			return nil
+		case update := <-updates:
+			fmt.Printf("The config has changed, restarting the watchers...\n")
+			cancel()
+			// the old watchers must have stopped before the new ones start or a failure could be reported twice
+			if err := <-done; err != nil {
+				fmt.Printf("Error stopping the watchers : %v\n", err)
+			}
+			flush(current.handlers()...)
+			current = update
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package bootstrap
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestReload tests that run() restarts the watchers when the config file changes and keeps the current config when the
+// update is invalid.
+func TestReload(t *testing.T) {
+
+	path := "./testReload.json"
+	if err := writeConfig(path, `{"namespace": "hubbub"}`); err != nil {
+		t.Fatalf("Error writing the config %v", err.Error())
+	}
+	defer os.Remove(path)
+
+	source := fileSource(path)
+	content, err := source()
+	if err != nil {
+		t.Fatalf("Error reading the config %v", err.Error())
+	}
+
+	current, err := loadConfig(content)
+	if err != nil {
+		t.Fatalf("Error loading the config %v", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan configUpdate)
+	go watchConfig(ctx, source, content, 10*time.Millisecond, updates)
+
+	started := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, current, updates, func(ctx context.Context, config *models.Config, handler models.NotificationHandler) error {
+			started <- config.Namespace
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	testSuite := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{name: "The watchers should start with the initial config", expected: "hubbub"},
+		{name: "A changed config should restart the watchers", config: `{"namespace": "payments"}`, expected: "payments"},
+		{name: "Invalid json should be rejected", config: `{"namespace": `},
+		{name: "A config that fails validation should be rejected", config: `{"namespaceInclude": ["[payments"]}`},
+		{name: "A config with an escalation handler that can not be initialized should be rejected",
+			config: `{"namespace": "payments", "escalations": [{"name": "pager", "steps": [{"after": 600, "notifications": {"type": "webhook"}}]}]}`},
+		{name: "A config with a digest handler that can not be initialized should be rejected",
+			config: `{"namespace": "payments", "digest": {"period": "daily", "notifications": {"type": "webhook"}}}`},
+		{name: "A valid config after a rejected one should restart the watchers", config: `{"namespace": "orders"}`, expected: "orders"},
+	}
+
+	for _, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testCase.name)
+
+		if testCase.config != "" {
+			if err := writeConfig(path, testCase.config); err != nil {
+				t.Fatalf("Error writing the config %v", err.Error())
+			}
+		}
+
+		if testCase.expected == "" {
+			select {
+			case namespace := <-started:
+				t.Errorf("Expected the watchers to keep running but they were restarted for %v", namespace)
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case namespace := <-started:
+			if namespace != testCase.expected {
+				t.Errorf("Expected the watchers to start for %v but received %v", testCase.expected, namespace)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Expected the watchers to start for %v", testCase.expected)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected run() to return nil once the context is done but received %v", err)
+	}
+
+}
+
+// writeConfig replaces the config at path atomically, the config is written to a temp file that is renamed over it. Writing
+// the file in place lets watchConfig read it while it is empty or half written.
+func writeConfig(path, config string) error {
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(config); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// TestWatchConfigEmpty tests that an empty config is not sent as an update, and that the config in use is not reloaded once
+// the config can be read again. HUBBUB_NAMESPACE is set so the empty config would pass validation as an env only config.
+func TestWatchConfigEmpty(t *testing.T) {
+
+	os.Setenv("HUBBUB_NAMESPACE", "hubbubTest")
+	defer os.Unsetenv("HUBBUB_NAMESPACE")
+
+	reads := [][]byte{{}, nil, []byte(`{"namespace": "hubbub"}`)}
+	source := func() ([]byte, error) {
+		content := reads[0]
+		if len(reads) > 1 {
+			reads = reads[1:]
+		}
+		return content, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates := make(chan configUpdate)
+	go watchConfig(ctx, source, []byte(`{"namespace": "hubbub"}`), 10*time.Millisecond, updates)
+
+	select {
+	case update := <-updates:
+		t.Errorf("Expected the empty config to be ignored but received an update for %v", update.config.Namespace)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+}
+
+// TestConfigMapSource tests reading the config from a ConfigMap.
+func TestConfigMapSource(t *testing.T) {
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "hubbub", Namespace: "monitoring"},
+		Data:       map[string]string{"config.json": `{"namespace": "hubbub"}`},
+	}
+
+	testSuite := map[string]struct {
+		configMap   string
+		key         string
+		expected    string
+		expectError bool
+	}{
+		"The key should be read from the ConfigMap": {
+			configMap: "monitoring/hubbub",
+			key:       "config.json",
+			expected:  `{"namespace": "hubbub"}`,
+		},
+		"A missing key should be an error": {
+			configMap:   "monitoring/hubbub",
+			key:         "hubbub.json",
+			expectError: true,
+		},
+		"A missing ConfigMap should be an error": {
+			configMap:   "monitoring/missing",
+			key:         "config.json",
+			expectError: true,
+		},
+		"A ConfigMap without a namespace should be an error": {
+			configMap:   "hubbub",
+			key:         "config.json",
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		var content []byte
+		source, err := configMapSource(fake.NewSimpleClientset(cm), testCase.configMap, testCase.key)
+		if err == nil {
+			content, err = source()
+		}
+
+		if (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error : %v, but received %v", testCase.expectError, err)
+		}
+
+		if string(content) != testCase.expected {
+			t.Errorf("Expected the config %v but received %v", testCase.expected, string(content))
+		}
+	}
+
+}
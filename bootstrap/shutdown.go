@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"fmt"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// shutdownTimeout is how long the watchers have to finish the notifications they are sending once Hubbub is asked to stop, and
+// flushTimeout how long a handler has to send what it has buffered. Together they fit in the default termination grace period of 30 seconds.
+const (
+	shutdownTimeout = 10 * time.Second
+	flushTimeout    = 10 * time.Second
+)
+
+// drain waits up to timeout for the watchers to return on done, a notification that is still being sent after that is dropped.
+func drain(done <-chan error, timeout time.Duration) {
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Printf("Error stopping the watchers : %v\n", err)
+		}
+	case <-time.After(timeout):
+		fmt.Printf("The watchers did not stop within %v, exiting anyway\n", timeout)
+	}
+}
+
+// flush sends anything buffered by the handlers before they are discarded, only handlers that implement models.Flusher buffer notifications.
+func flush(handlers ...models.NotificationHandler) {
+
+	for _, handler := range handlers {
+		if f, ok := handler.(models.Flusher); ok {
+			if err := f.Flush(flushTimeout); err != nil {
+				fmt.Printf("Error flushing the notifications : %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// flushingHandler is a NotificationHandler that buffers notifications until it is flushed.
+type flushingHandler struct {
+	buffered int
+	sent     int
+	flushes  int
+}
+
+func (f *flushingHandler) Init(config *models.Config) error { return nil }
+
+func (f *flushingHandler) Notify(details models.NotificationDetails) error {
+	f.buffered++
+	return nil
+}
+
+func (f *flushingHandler) Flush(timeout time.Duration) error {
+	f.sent += f.buffered
+	f.buffered = 0
+	f.flushes++
+	return nil
+}
+
+// TestShutdown tests that run() lets the watchers finish the notification they are sending once ctx is done and flushes
+// the handler before returning.
+func TestShutdown(t *testing.T) {
+
+	testSuite := map[string]struct {
+		// the time the watchers take to finish the notification in flight after ctx is done
+		inFlight      time.Duration
+		expectedSent  int
+		expectedFlush int
+	}{
+		"A notification in flight should be sent before the handler is flushed": {
+			inFlight:      50 * time.Millisecond,
+			expectedSent:  1,
+			expectedFlush: 1,
+		},
+		"The handler should be flushed when nothing is in flight": {
+			expectedFlush: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &flushingHandler{}
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+		done := make(chan error, 1)
+
+		go func() {
+			done <- run(ctx, configUpdate{config: &models.Config{}, handler: handler}, nil, func(ctx context.Context, config *models.Config, handler models.NotificationHandler) error {
+				close(started)
+				<-ctx.Done()
+				if testCase.inFlight > 0 {
+					time.Sleep(testCase.inFlight)
+					handler.Notify(models.NotificationDetails{})
+				}
+				return nil
+			})
+		}()
+
+		<-started
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected run() to return nil but received %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected run() to return once the context is done")
+		}
+
+		if handler.sent != testCase.expectedSent || handler.flushes != testCase.expectedFlush {
+			t.Errorf("Expected %v notification(s) sent by %v flush(es) but received %v and %v", testCase.expectedSent, testCase.expectedFlush, handler.sent, handler.flushes)
+		}
+	}
+
+}
+
+// TestDrain tests that drain() does not wait past its timeout for watchers that do not stop.
+func TestDrain(t *testing.T) {
+
+	started := time.Now()
+	drain(make(chan error), 50*time.Millisecond)
+
+	if waited := time.Since(started); waited > time.Second {
+		t.Errorf("Expected drain() to give up after 50ms but it waited %v", waited)
+	}
+
+}
@@ -2,18 +2,38 @@ package helpers
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"gihutb.com/jxmoore/hubbub/models"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
-// GetKubeClient pulls the InClusterConfig and returns the clientset
-func GetKubeClient() (*kubernetes.Clientset, error) {
+// GetKubeConfig builds the *rest.Config used to talk to the cluster. The kubeconfig is resolved in the usual order,
+// an explicit path (the -kubeconfig flag), then the KUBECONFIG enviroment variable and finally ~/.kube/config.
+// If none of those exist the InClusterConfig is used. kubeContext, if set, overrides the current-context of the kubeconfig.
+func GetKubeConfig(kubeConfig, kubeContext string) (*rest.Config, error) {
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeConfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+
+	// The deferred loader falls back to the InClusterConfig when no kubeconfig could be found
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetKubeClient pulls the kubernetes config (see GetKubeConfig) and returns the clientset
+func GetKubeClient(kubeConfig, kubeContext string) (*kubernetes.Clientset, error) {
 
 	// Get the config
-	config, err := rest.InClusterConfig()
+	config, err := GetKubeConfig(kubeConfig, kubeContext)
 	if err != nil {
 		return nil, fmt.Errorf("error getting config : %v", err)
 	}
@@ -28,10 +48,73 @@ func GetKubeClient() (*kubernetes.Clientset, error) {
 	return kubeClient, nil
 }
 
+// NewHandler returns the NotificationHandler named by the notifications in the config, initialized with the config. Hubbub writes
+// the notifications to STDOUT when the type is not known.
+func NewHandler(config *models.Config) (models.NotificationHandler, error) {
+
+	var handler models.NotificationHandler
+	switch strings.ToLower(config.Notification.Handler) {
+	case "slack", "sl":
+		handler = new(models.Slack)
+	case "appinsights", "ai", "applicationinsights":
+		handler = new(models.ApplicationInsights)
+	case "webhook":
+		handler = new(models.Webhook)
+	default:
+		handler = new(models.STDOUT)
+	}
+
+	if err := handler.Init(config); err != nil {
+		return nil, err
+	}
+
+	return handler, nil
+}
+
 // NewNotification calls the methods on the NotificationHandler interface that process a notification.
 func NewNotification(handler models.NotificationHandler, pod models.PodStatusInformation) error {
+	return send(handler, func() (models.NotificationDetails, error) { return models.BuildBody(handler, pod) })
+}
+
+// NewSummaryNotification sends a single notification for a list of pods, see models.BuildSummaryBody.
+func NewSummaryNotification(handler models.NotificationHandler, pods []models.PodStatusInformation) error {
+	return send(handler, func() (models.NotificationDetails, error) { return models.BuildSummaryBody(handler, pods) })
+}
+
+// NewGroupNotification sends a single notification for a group of pods that failed the same way, see models.BuildGroupBody.
+func NewGroupNotification(handler models.NotificationHandler, group models.FailureGroup) error {
+	return send(handler, func() (models.NotificationDetails, error) { return models.BuildGroupBody(handler, group) })
+}
+
+// NewResolvedNotification sends the notification for an incident that has been resolved, see models.BuildResolvedBody.
+func NewResolvedNotification(handler models.NotificationHandler, incident models.Incident) error {
+	return send(handler, func() (models.NotificationDetails, error) { return models.BuildResolvedBody(handler, incident) })
+}
+
+// NewEscalationNotification sends the notification for a step (starting at 1) of the escalation policy of an incident through
+// the handler of the step, see models.BuildEscalationBody.
+func NewEscalationNotification(incident models.Incident, policy models.EscalationPolicy, step int) error {
+
+	handler := policy.Steps[step-1].Handler
+	if handler == nil {
+		return fmt.Errorf("step %v of the escalation policy %v has no handler", step, policy.Name)
+	}
+
+	return send(handler, func() (models.NotificationDetails, error) {
+		return models.BuildEscalationBody(handler, incident, policy, step)
+	})
+}
+
+// NewDigestNotification sends a digest of the failures over its period, each list is cut down to its top entries. See
+// models.BuildDigestBody.
+func NewDigestNotification(handler models.NotificationHandler, digest models.Digest, top int) error {
+	return send(handler, func() (models.NotificationDetails, error) { return models.BuildDigestBody(handler, digest, top) })
+}
+
+// send builds a notification with build and sends it through the handler.
+func send(handler models.NotificationHandler, build func() (models.NotificationDetails, error)) error {
 
-	msg, err := models.BuildBody(handler, pod)
+	msg, err := build()
 	if err != nil {
 		return fmt.Errorf("error building notification body %v", err)
 	}
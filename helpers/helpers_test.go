@@ -1,18 +1,25 @@
 package helpers
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
 	"gihutb.com/jxmoore/hubbub/models"
 )
 
 // pod is a package wide PodStatusInformation{} used in all of the herlper tests as a base.
 var testPod = models.PodStatusInformation{
-	Namespace:     "hubbub",
-	PodName:       "hubbub",
-	ContainerName: "hubbub",
-	Image:         "hubbub",
-	ExitCode:      2,
-	Reason:        "hubbub",
-	Message:       "hubbub",
+	Namespace: "hubbub",
+	PodName:   "hubbub",
+	Failures: []models.ContainerFailure{{
+		ContainerName: "hubbub",
+		Image:         "hubbub",
+		ExitCode:      2,
+		Reason:        "hubbub",
+		Message:       "hubbub",
+	}},
 }
 
 func ExampleNewNotification() {
@@ -25,6 +32,140 @@ func ExampleNewNotification() {
 	NewNotification(handler, p)
 
 	// Output:
-	// {"Namespace":"hubbub-Testin","PodName":"hubbub","ContainerName":"hubbub","Image":"hubbub","StartedAt":"0001-01-01T00:00:00Z","FinishedAt":"0001-01-01T00:00:00Z","ExitCode":2,"Reason":"hubbub","Message":"hubbub","Seen":"0001-01-01T00:00:00Z"}
+	// {"Namespace":"hubbub-Testin","PodName":"hubbub","StartedAt":"0001-01-01T00:00:00Z","Reason":"","Message":"","Seen":"0001-01-01T00:00:00Z","Failures":[{"ContainerName":"hubbub","Image":"hubbub","FinishedAt":"0001-01-01T00:00:00Z","ExitCode":2,"Reason":"hubbub","Message":"hubbub"}]}
+
+}
+
+// testKubeConfig is a kubeconfig with two contexts, each pointing at a different server.
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: one
+  cluster:
+    server: https://one.hubbub.local
+- name: two
+  cluster:
+    server: https://two.hubbub.local
+contexts:
+- name: one
+  context:
+    cluster: one
+    user: hubbub
+- name: two
+  context:
+    cluster: two
+    user: hubbub
+current-context: one
+users:
+- name: hubbub
+  user:
+    token: hubbub
+`
+
+// TestGetKubeConfig tests GetKubeConfig(), verifying the kubeconfig path, the KUBECONFIG enviroment variable and the context
+// override are all honored.
+func TestGetKubeConfig(t *testing.T) {
+
+	kubeConfigPath := "./testKubeConfig"
+	if err := ioutil.WriteFile(kubeConfigPath, []byte(testKubeConfig), 0666); err != nil {
+		t.Fatalf("Error writing the kubeconfig %v", err.Error())
+	}
+	defer os.Remove(kubeConfigPath)
+
+	testSuite := map[string]struct {
+		kubeConfig   string
+		kubeContext  string
+		envVar       string
+		expectedHost string
+		expectError  bool
+	}{
+		"The explicit kubeconfig should use the current-context": {
+			kubeConfig:   kubeConfigPath,
+			expectedHost: "https://one.hubbub.local",
+		},
+		"The explicit kubeconfig should honor the context override": {
+			kubeConfig:   kubeConfigPath,
+			kubeContext:  "two",
+			expectedHost: "https://two.hubbub.local",
+		},
+		"The KUBECONFIG enviroment variable should be used when no path is passed": {
+			envVar:       kubeConfigPath,
+			kubeContext:  "two",
+			expectedHost: "https://two.hubbub.local",
+		},
+		"A missing kubeconfig should return an error": {
+			kubeConfig:  "./noRealFile/kubeconfig",
+			expectError: true,
+		},
+		"An unknown context should return an error": {
+			kubeConfig:  kubeConfigPath,
+			kubeContext: "three",
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		os.Setenv("KUBECONFIG", testCase.envVar)
+		config, err := GetKubeConfig(testCase.kubeConfig, testCase.kubeContext)
+		os.Unsetenv("KUBECONFIG")
+
+		if testCase.expectError {
+			if err == nil {
+				t.Errorf("Expected GetKubeConfig() to return an error but received nil")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Error on GetKubeConfig() %v", err)
+		} else if config.Host != testCase.expectedHost {
+			t.Errorf("Expected the host %v but received %v", testCase.expectedHost, config.Host)
+		}
+	}
+
+}
+
+// TestNewHandler tests that NewHandler() returns the handler named in the config and reports a handler that can not be initialized.
+func TestNewHandler(t *testing.T) {
+
+	testSuite := map[string]struct {
+		notifications models.NotificationConfig
+		expectedType  string
+		expectError   bool
+	}{
+		"An unknown type should write to STDOUT": {
+			expectedType: "*models.STDOUT",
+		},
+		"Slack should be initialized": {
+			notifications: models.NotificationConfig{Handler: "Slack", SlackWebHook: "https://hooks.slack.local", SlackChannel: "#alerts"},
+			expectedType:  "*models.Slack",
+		},
+		"A webhook should be initialized": {
+			notifications: models.NotificationConfig{Handler: "webhook", WebhookURL: "https://events.pager.local"},
+			expectedType:  "*models.Webhook",
+		},
+		"A webhook without a url should fail": {
+			notifications: models.NotificationConfig{Handler: "webhook"},
+			expectError:   true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		config := &models.Config{Notification: testCase.notifications}
+		handler, err := NewHandler(config)
+		if (err != nil) != testCase.expectError {
+			t.Fatalf("Expected an error : %v, but received %v", testCase.expectError, err)
+		}
+
+		if handlerType := fmt.Sprintf("%T", handler); err == nil && handlerType != testCase.expectedType {
+			t.Errorf("Expected the handler %v but received %v", testCase.expectedType, handlerType)
+		}
+	}
 
 }
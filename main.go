@@ -1,20 +1,51 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"gihutb.com/jxmoore/hubbub/bootstrap"
+	"gihutb.com/jxmoore/hubbub/silence"
 )
 
 var configPath = flag.String("c", "./config.json", "The path for the config file.")
+var configMap = flag.String("configmap", "", "Read the config from a ConfigMap (namespace/name) instead of a file, the key is the file name of -c.")
 var envOnly = flag.Bool("e", false, "Use only enviroment variables.")
+var kubeConfig = flag.String("kubeconfig", "", "The path for a kubeconfig file, if omitted KUBECONFIG, ~/.kube/config and then the InClusterConfig are tried.")
+var kubeContext = flag.String("context", "", "The kubeconfig context to use, defaults to the current-context.")
+var apiAddress = flag.String("api", "", "The address to serve the silence API on, e.g. :8080 which is served on localhost. If omitted the API is not served.")
+var apiToken = flag.String("api-token", os.Getenv(silence.TokenEnv), "The bearer token required by the silence API, the default is "+silence.TokenEnv+".")
 
 func main() {
 
+	// hubbub silence ... manages the silences of a running Hubbub through its API
+	if len(os.Args) > 1 && os.Args[1] == "silence" {
+		if err := silence.RunCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
 	flag.Parse()
 
-	if err := bootstrap.BootStrap(*configPath, *envOnly); err != nil {
+	// Kubernetes sends a SIGTERM before killing the pod, the watchers are stopped and the notifications being sent are finished
+	// so a rollout does not lose any alerts. A second signal is not caught and kills Hubbub straight away.
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		s := <-signals
+		signal.Stop(signals)
+		fmt.Printf("Received %v, shutting down...\n", s)
+		cancel()
+	}()
+
+	if err := bootstrap.BootStrap(ctx, *configPath, *configMap, *envOnly, *kubeConfig, *kubeContext, *apiAddress, *apiToken); err != nil {
 		log.Fatal(err.Error())
 	}
 
@@ -6,9 +6,51 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultEventReasons are the Warning event reasons that generate notifications when none are supplied in the config.
+var DefaultEventReasons = []string{
+	"FailedScheduling",
+	"FailedMount",
+	"FailedAttachVolume",
+	"FailedCreatePodSandBox",
+	"BackOff",
+	"Unhealthy",
+}
+
+// The Backfill modes. BackfillReport sends a single summary of the pods that are already failed when the watches start and
+// BackfillSeed only records them, so neither mode sends a notification for each of those pods.
+const (
+	BackfillReport = "report"
+	BackfillSeed   = "seed"
+)
+
+// The Store types and the default name of the ConfigMap the state is kept in.
+const (
+	StoreFile             = "file"
+	StoreConfigMap        = "configmap"
+	DefaultStoreConfigMap = "hubbub-state"
+)
+
+// DefaultGroupMaxWait is the most seconds the first failure of a group waits before the group is sent, when grouping is enabled
+// without a max wait.
+const DefaultGroupMaxWait = 60
+
+// The leader election defaults. A follower takes over at most LeaseDuration seconds after the leader dies, and straight away
+// if the leader shuts down cleanly as the lease is released.
+const (
+	DefaultLeaseName     = "hubbub"
+	DefaultLeaseDuration = 15
+	DefaultRenewDeadline = 10
+	DefaultRetryPeriod   = 2
 )
 
 // Config is the struct that contains all of the hubbub config
@@ -19,19 +61,113 @@ type Config struct {
 	TimeCheck    int            `json:"time"`
 	TimeZone     string         `json:"timezone"`
 	TimeLocation *time.Location `json:"-"`
-	//	Labels       string         `json:"labels"` // TODO, currently not implemented
-	Notification struct {
-		Handler string `json:"type"`
-		// Slack specifics
-		SlackWebHook string `json:"slackWebhook,omitempty"`
-		SlackChannel string `json:"slackChannel,omitempty"`
-		SlackTitle   string `json:"slackTitle,omitempty"`
-		SlackUser    string `json:"slackUser,omitempty"`
-		SlackIcon    string `json:"slackIcon,omitempty"`
-		// Application Insights
-		AppInsightsKey   string `json:"instrumentationKey,omitempty"`
-		CustomEventTitle string `json:"customEventTitle.omitempty"`
-	} `json:"notifications"`
+	Labels       string         `json:"labels"` // label selector passed to the watch, e.g. tier=backend,!job-name
+	Fields       string         `json:"fields"` // field selector passed to the watch, e.g. spec.nodeName=node-1
+
+	// Namespaces is a list of additional namespaces to watch, one watch is created per namespace.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// AllNamespaces watches the entire cluster with a single watch, NamespaceInclude/NamespaceExclude
+	// are glob patterns that can be used to filter the namespaces that generate notifications.
+	AllNamespaces    bool     `json:"allNamespaces,omitempty"`
+	NamespaceInclude []string `json:"namespaceInclude,omitempty"`
+	NamespaceExclude []string `json:"namespaceExclude,omitempty"`
+
+	// Events enables a second watch on the Warning events for pods, Reasons limits the notifications to events with
+	// one of the listed reasons (DefaultEventReasons when empty, '*' for every reason).
+	Events struct {
+		Enabled bool     `json:"enabled"`
+		Reasons []string `json:"reasons,omitempty"`
+	} `json:"events"`
+
+	// Backfill lists the pods when the watches start to find the ones that failed while Hubbub was not running, it is either
+	// BackfillReport or BackfillSeed. When empty the pods that are already failed are not checked.
+	Backfill string `json:"backfill,omitempty"`
+
+	// Logs attaches the last Lines lines (capped at Bytes bytes) of a failed container's logs to the notification. Redact is
+	// a list of regular expressions, anything matching them is replaced with [REDACTED] before the logs are sent.
+	Logs struct {
+		Enabled bool     `json:"enabled"`
+		Lines   int64    `json:"lines,omitempty"`
+		Bytes   int64    `json:"bytes,omitempty"`
+		Redact  []string `json:"redact,omitempty"`
+	} `json:"logs"`
+
+	// Grouping buffers the failures of a workload that have the same reason and exit code so they are sent as a single notification.
+	// A group is sent Window seconds after its last failure, or MaxWait seconds after its first failure if that is sooner. When
+	// Window is 0 each failure is sent straight away.
+	Grouping struct {
+		Window  int `json:"window,omitempty"`
+		MaxWait int `json:"maxWait,omitempty"`
+	} `json:"grouping"`
+
+	// LeaderElection allows more than one replica of Hubbub to run, only the replica holding the Lease LeaseName in LeaseNamespace
+	// watches the cluster and sends notifications. The durations are in seconds, see DefaultLeaseName and the
+	// other Default* constants for the defaults.
+	LeaderElection struct {
+		Enabled        bool   `json:"enabled"`
+		LeaseName      string `json:"leaseName,omitempty"`
+		LeaseNamespace string `json:"leaseNamespace,omitempty"`
+		LeaseDuration  int    `json:"leaseDuration,omitempty"`
+		RenewDeadline  int    `json:"renewDeadline,omitempty"`
+		RetryPeriod    int    `json:"retryPeriod,omitempty"`
+	} `json:"leaderElection"`
+
+	// Store persists the dedup state so a restart or a new leader does not notify the same failures again. Type is StoreFile, which
+	// uses the file at Path, or StoreConfigMap, which uses the ConfigMap ConfigMap (name or namespace/name). When empty the state is only
+	// kept in memory.
+	Store struct {
+		Type      string `json:"type,omitempty"`
+		Path      string `json:"path,omitempty"`
+		ConfigMap string `json:"configMap,omitempty"`
+	} `json:"store"`
+
+	// Silences suppress the notifications for the failures they match during their window, see Silence. Silences can also be
+	// created while Hubbub is running through the silence API.
+	Silences []Silence `json:"silences,omitempty"`
+
+	// Severity classifies the failures as SeverityCritical, SeverityWarning or SeverityInfo. The first of the Rules that matches a failure
+	// sets its severity and Default is used when none match, a severity set with the hubbub.io/severity annotation takes precedence. The
+	// failures less severe than Minimum do not generate notifications, Channels maps a severity to the slack channel it is posted to.
+	Severity struct {
+		Default  string            `json:"default,omitempty"`
+		Minimum  string            `json:"minimum,omitempty"`
+		Rules    []SeverityRule    `json:"rules,omitempty"`
+		Channels map[string]string `json:"channels,omitempty"`
+	} `json:"severity"`
+
+	// Escalations send a second notification through another handler for the workloads that are still failing a while after they
+	// were first notified, see EscalationPolicy.
+	Escalations []EscalationPolicy `json:"escalations,omitempty"`
+
+	// Digest sends a report of the failures seen over each Period, which is DigestHourly or DigestDaily, listing the Top namespaces,
+	// workloads, reasons, exit codes and pods along with the incidents that are still open. A daily digest is sent at Hour (0-23) in the
+	// timezone of the config. The digest is sent with the handler in Notification, or the handler of the config when it has no type.
+	// Handler is initialized from Notification when the config is loaded. When Period is empty no digest is sent.
+	Digest struct {
+		Period       string              `json:"period,omitempty"`
+		Hour         int                 `json:"hour,omitempty"`
+		Top          int                 `json:"top,omitempty"`
+		Notification NotificationConfig  `json:"notifications"`
+		Handler      NotificationHandler `json:"-"`
+	} `json:"digest"`
+
+	Notification NotificationConfig `json:"notifications"`
+}
+
+// NotificationConfig is the handler that the notifications are sent with and its settings, Handler is the type of the handler.
+type NotificationConfig struct {
+	Handler string `json:"type"`
+	// Slack specifics
+	SlackWebHook string `json:"slackWebhook,omitempty"`
+	SlackChannel string `json:"slackChannel,omitempty"`
+	SlackTitle   string `json:"slackTitle,omitempty"`
+	SlackUser    string `json:"slackUser,omitempty"`
+	SlackIcon    string `json:"slackIcon,omitempty"`
+	// Application Insights
+	AppInsightsKey   string `json:"instrumentationKey,omitempty"`
+	CustomEventTitle string `json:"customEventTitle.omitempty"`
+	// Webhook, the notifications are posted as json
+	WebhookURL string `json:"webhookUrl,omitempty"`
 }
 
 // Load attempts to read the config file and unmarshel it into 'c'
@@ -49,6 +185,13 @@ func (c *Config) Load(configFile string) error {
 		return fmt.Errorf("error reading file %v.\n%v ", configFile, err.Error())
 	}
 
+	return c.Parse(content)
+}
+
+// Parse unmarshels the json config in content into 'c', it is used by Load and when the config is read from a ConfigMap.
+func (c *Config) Parse(content []byte) error {
+
+	var err error
 	if len(content) == 0 {
 		return nil
 	}
@@ -70,8 +213,8 @@ func (c *Config) Load(configFile string) error {
 
 // LoadEnvVars will pull the associated enviroment variables and assign them to 'c' if they
 // are missing from 'c' and present as env variables. Some values, such as the icon, have a default value which is
-// assigned here if 'c' and the env variable is nil.
-func (c *Config) LoadEnvVars() {
+// assigned here if 'c' and the env variable is nil. An error is returned if one of the json env variables is malformed.
+func (c *Config) LoadEnvVars() error {
 
 	var err error
 	if !c.Debug && os.Getenv("HUBBUB_DEBUG") != "" {
@@ -83,6 +226,221 @@ func (c *Config) LoadEnvVars() {
 	if c.Namespace == "" && os.Getenv("HUBBUB_NAMESAPCE") != "" {
 		c.Namespace = os.Getenv("HUBBUB_NAMESAPCE")
 	}
+	if len(c.Namespaces) == 0 && os.Getenv("HUBBUB_NAMESPACES") != "" {
+		c.Namespaces = splitList(os.Getenv("HUBBUB_NAMESPACES"))
+	}
+	if !c.AllNamespaces && os.Getenv("HUBBUB_ALLNAMESPACES") != "" {
+		all, err := strconv.ParseBool(os.Getenv("HUBBUB_ALLNAMESPACES"))
+		if err == nil {
+			c.AllNamespaces = all
+		}
+	}
+	if len(c.NamespaceInclude) == 0 && os.Getenv("HUBBUB_NAMESPACE_INCLUDE") != "" {
+		c.NamespaceInclude = splitList(os.Getenv("HUBBUB_NAMESPACE_INCLUDE"))
+	}
+	if len(c.NamespaceExclude) == 0 && os.Getenv("HUBBUB_NAMESPACE_EXCLUDE") != "" {
+		c.NamespaceExclude = splitList(os.Getenv("HUBBUB_NAMESPACE_EXCLUDE"))
+	}
+	if !c.Events.Enabled && os.Getenv("HUBBUB_EVENTS") != "" {
+		events, err := strconv.ParseBool(os.Getenv("HUBBUB_EVENTS"))
+		if err == nil {
+			c.Events.Enabled = events
+		}
+	}
+	if len(c.Events.Reasons) == 0 && os.Getenv("HUBBUB_EVENT_REASONS") != "" {
+		c.Events.Reasons = splitList(os.Getenv("HUBBUB_EVENT_REASONS"))
+	}
+	if c.Backfill == "" && os.Getenv("HUBBUB_BACKFILL") != "" {
+		c.Backfill = os.Getenv("HUBBUB_BACKFILL")
+	}
+	c.Backfill = strings.ToLower(c.Backfill)
+	if !c.Logs.Enabled && os.Getenv("HUBBUB_LOGS") != "" {
+		logs, err := strconv.ParseBool(os.Getenv("HUBBUB_LOGS"))
+		if err == nil {
+			c.Logs.Enabled = logs
+		}
+	}
+	if c.Logs.Lines == 0 && os.Getenv("HUBBUB_LOG_LINES") != "" {
+		lines, err := strconv.ParseInt(os.Getenv("HUBBUB_LOG_LINES"), 10, 64)
+		if err == nil {
+			c.Logs.Lines = lines
+		}
+	}
+	if c.Logs.Lines <= 0 {
+		c.Logs.Lines = 20
+	}
+	if c.Logs.Bytes == 0 && os.Getenv("HUBBUB_LOG_BYTES") != "" {
+		bytes, err := strconv.ParseInt(os.Getenv("HUBBUB_LOG_BYTES"), 10, 64)
+		if err == nil {
+			c.Logs.Bytes = bytes
+		}
+	}
+	if c.Logs.Bytes <= 0 {
+		c.Logs.Bytes = 2048
+	}
+	if len(c.Logs.Redact) == 0 && os.Getenv("HUBBUB_LOG_REDACT") != "" {
+		// a json array rather than a comma seperated list as the patterns themselves can contain commas, e.g. \d{3,4}
+		redact := []string{}
+		if err := json.Unmarshal([]byte(os.Getenv("HUBBUB_LOG_REDACT")), &redact); err != nil {
+			return fmt.Errorf("invalid HUBBUB_LOG_REDACT, it must be a json array of patterns : %v", err)
+		}
+		c.Logs.Redact = redact
+	}
+	if !c.LeaderElection.Enabled && os.Getenv("HUBBUB_LEADER_ELECTION") != "" {
+		leaderElection, err := strconv.ParseBool(os.Getenv("HUBBUB_LEADER_ELECTION"))
+		if err == nil {
+			c.LeaderElection.Enabled = leaderElection
+		}
+	}
+	if c.LeaderElection.LeaseName == "" && os.Getenv("HUBBUB_LEASE_NAME") != "" {
+		c.LeaderElection.LeaseName = os.Getenv("HUBBUB_LEASE_NAME")
+	} else if c.LeaderElection.LeaseName == "" {
+		c.LeaderElection.LeaseName = DefaultLeaseName
+	}
+	if c.LeaderElection.LeaseNamespace == "" && os.Getenv("HUBBUB_LEASE_NAMESPACE") != "" {
+		c.LeaderElection.LeaseNamespace = os.Getenv("HUBBUB_LEASE_NAMESPACE")
+	}
+	if c.LeaderElection.LeaseDuration == 0 && os.Getenv("HUBBUB_LEASE_DURATION") != "" {
+		seconds, err := strconv.Atoi(os.Getenv("HUBBUB_LEASE_DURATION"))
+		if err == nil {
+			c.LeaderElection.LeaseDuration = seconds
+		}
+	}
+	if c.LeaderElection.RenewDeadline == 0 && os.Getenv("HUBBUB_RENEW_DEADLINE") != "" {
+		seconds, err := strconv.Atoi(os.Getenv("HUBBUB_RENEW_DEADLINE"))
+		if err == nil {
+			c.LeaderElection.RenewDeadline = seconds
+		}
+	}
+	if c.LeaderElection.RetryPeriod == 0 && os.Getenv("HUBBUB_RETRY_PERIOD") != "" {
+		seconds, err := strconv.Atoi(os.Getenv("HUBBUB_RETRY_PERIOD"))
+		if err == nil {
+			c.LeaderElection.RetryPeriod = seconds
+		}
+	}
+	if c.LeaderElection.LeaseDuration == 0 {
+		c.LeaderElection.LeaseDuration = DefaultLeaseDuration
+	}
+	if c.LeaderElection.RenewDeadline == 0 {
+		c.LeaderElection.RenewDeadline = DefaultRenewDeadline
+	}
+	if c.LeaderElection.RetryPeriod == 0 {
+		c.LeaderElection.RetryPeriod = DefaultRetryPeriod
+	}
+	if c.Grouping.Window == 0 && os.Getenv("HUBBUB_GROUP_WINDOW") != "" {
+		seconds, err := strconv.Atoi(os.Getenv("HUBBUB_GROUP_WINDOW"))
+		if err == nil {
+			c.Grouping.Window = seconds
+		}
+	}
+	if c.Grouping.MaxWait == 0 && os.Getenv("HUBBUB_GROUP_MAX_WAIT") != "" {
+		seconds, err := strconv.Atoi(os.Getenv("HUBBUB_GROUP_MAX_WAIT"))
+		if err == nil {
+			c.Grouping.MaxWait = seconds
+		}
+	}
+	if c.Grouping.Window > 0 && c.Grouping.MaxWait == 0 {
+		c.Grouping.MaxWait = DefaultGroupMaxWait
+	}
+	if len(c.Silences) == 0 && os.Getenv("HUBBUB_SILENCES") != "" {
+		silences := []Silence{}
+		if err := json.Unmarshal([]byte(os.Getenv("HUBBUB_SILENCES")), &silences); err != nil {
+			return fmt.Errorf("invalid HUBBUB_SILENCES : %v", err)
+		}
+		c.Silences = silences
+	}
+	for i := range c.Silences {
+		if c.Silences[i].ID == "" {
+			c.Silences[i].ID = "config-" + strconv.Itoa(i+1)
+		}
+	}
+	if c.Severity.Default == "" && os.Getenv("HUBBUB_SEVERITY_DEFAULT") != "" {
+		c.Severity.Default = os.Getenv("HUBBUB_SEVERITY_DEFAULT")
+	}
+	c.Severity.Default = strings.ToLower(c.Severity.Default)
+	if c.Severity.Minimum == "" && os.Getenv("HUBBUB_SEVERITY_MINIMUM") != "" {
+		c.Severity.Minimum = os.Getenv("HUBBUB_SEVERITY_MINIMUM")
+	}
+	c.Severity.Minimum = strings.ToLower(c.Severity.Minimum)
+	if len(c.Severity.Rules) == 0 && os.Getenv("HUBBUB_SEVERITY_RULES") != "" {
+		rules := []SeverityRule{}
+		if err := json.Unmarshal([]byte(os.Getenv("HUBBUB_SEVERITY_RULES")), &rules); err != nil {
+			return fmt.Errorf("invalid HUBBUB_SEVERITY_RULES : %v", err)
+		}
+		c.Severity.Rules = rules
+	}
+	for i := range c.Severity.Rules {
+		c.Severity.Rules[i].Severity = strings.ToLower(c.Severity.Rules[i].Severity)
+	}
+	if len(c.Severity.Channels) == 0 && os.Getenv("HUBBUB_SEVERITY_CHANNELS") != "" {
+		c.Severity.Channels = map[string]string{}
+		for _, pair := range splitList(os.Getenv("HUBBUB_SEVERITY_CHANNELS")) {
+			if parts := strings.SplitN(pair, "=", 2); len(parts) == 2 {
+				c.Severity.Channels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	channels := map[string]string{}
+	for severity, channel := range c.Severity.Channels {
+		channels[strings.ToLower(severity)] = channel
+	}
+	c.Severity.Channels = channels
+	if len(c.Escalations) == 0 && os.Getenv("HUBBUB_ESCALATIONS") != "" {
+		escalations := []EscalationPolicy{}
+		if err := json.Unmarshal([]byte(os.Getenv("HUBBUB_ESCALATIONS")), &escalations); err != nil {
+			return fmt.Errorf("invalid HUBBUB_ESCALATIONS : %v", err)
+		}
+		c.Escalations = escalations
+	}
+	for i := range c.Escalations {
+		if c.Escalations[i].Name == "" {
+			c.Escalations[i].Name = "escalation-" + strconv.Itoa(i+1)
+		}
+		for j := range c.Escalations[i].Severities {
+			c.Escalations[i].Severities[j] = strings.ToLower(c.Escalations[i].Severities[j])
+		}
+		for j := range c.Escalations[i].Steps {
+			c.Escalations[i].Steps[j].Notification.Handler = strings.ToLower(c.Escalations[i].Steps[j].Notification.Handler)
+		}
+	}
+	if c.Digest.Period == "" && os.Getenv("HUBBUB_DIGEST") != "" {
+		c.Digest.Period = os.Getenv("HUBBUB_DIGEST")
+	}
+	c.Digest.Period = strings.ToLower(c.Digest.Period)
+	if c.Digest.Hour == 0 && os.Getenv("HUBBUB_DIGEST_HOUR") != "" {
+		hour, err := strconv.Atoi(os.Getenv("HUBBUB_DIGEST_HOUR"))
+		if err == nil {
+			c.Digest.Hour = hour
+		}
+	}
+	if c.Digest.Top == 0 && os.Getenv("HUBBUB_DIGEST_TOP") != "" {
+		top, err := strconv.Atoi(os.Getenv("HUBBUB_DIGEST_TOP"))
+		if err == nil {
+			c.Digest.Top = top
+		}
+	}
+	if c.Digest.Top == 0 {
+		c.Digest.Top = DefaultDigestTop
+	}
+	c.Digest.Notification.Handler = strings.ToLower(c.Digest.Notification.Handler)
+	if c.Store.Type == "" && os.Getenv("HUBBUB_STORE") != "" {
+		c.Store.Type = os.Getenv("HUBBUB_STORE")
+	}
+	c.Store.Type = strings.ToLower(c.Store.Type)
+	if c.Store.Path == "" && os.Getenv("HUBBUB_STORE_PATH") != "" {
+		c.Store.Path = os.Getenv("HUBBUB_STORE_PATH")
+	}
+	if c.Store.ConfigMap == "" && os.Getenv("HUBBUB_STORE_CONFIGMAP") != "" {
+		c.Store.ConfigMap = os.Getenv("HUBBUB_STORE_CONFIGMAP")
+	} else if c.Store.ConfigMap == "" {
+		c.Store.ConfigMap = DefaultStoreConfigMap
+	}
+	if c.Labels == "" && os.Getenv("HUBBUB_LABELS") != "" {
+		c.Labels = os.Getenv("HUBBUB_LABELS")
+	}
+	if c.Fields == "" && os.Getenv("HUBBUB_FIELDS") != "" {
+		c.Fields = os.Getenv("HUBBUB_FIELDS")
+	}
 	if c.TimeCheck == 0 && os.Getenv("HUBBUB_TIMECHECK") != "" {
 		timeEnv, err := strconv.Atoi(os.Getenv("HUBBUB_TIMECHECK"))
 		if err != nil {
@@ -129,6 +487,9 @@ func (c *Config) LoadEnvVars() {
 	} else if c.Notification.SlackTitle == "" && os.Getenv("HUBBUB_TITLE") == "" {
 		c.Notification.SlackTitle = "There has been a pod error in production!"
 	}
+	if c.Notification.WebhookURL == "" && os.Getenv("HUBBUB_WEBHOOK_URL") != "" {
+		c.Notification.WebhookURL = os.Getenv("HUBBUB_WEBHOOK_URL")
+	}
 	if c.Notification.AppInsightsKey == "" && os.Getenv("HUBBUB_AIKEY") != "" {
 		c.Notification.AppInsightsKey = os.Getenv("HUBBUB_AIKEY")
 	}
@@ -138,4 +499,230 @@ func (c *Config) LoadEnvVars() {
 		c.Notification.CustomEventTitle = "There has been a pod error in production!"
 	}
 
+	return nil
+}
+
+// Validate checks that the fields in 'c' are usable and returns the first one that is not, the rules for each field are listed
+// in docs/Config.md.
+func (c *Config) Validate() error {
+
+	if len(c.WatchedNamespaces()) == 0 {
+		return fmt.Errorf("please ensure the config has a Namespace, Namespaces or AllNamespaces specified")
+	}
+
+	for _, patterns := range [][]string{c.NamespaceInclude, c.NamespaceExclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid namespace pattern '%v' : %v", pattern, err)
+			}
+		}
+	}
+
+	if _, err := labels.Parse(c.Labels); err != nil {
+		return fmt.Errorf("invalid label selector '%v' : %v", c.Labels, err)
+	}
+
+	if _, err := fields.ParseSelector(c.Fields); err != nil {
+		return fmt.Errorf("invalid field selector '%v' : %v", c.Fields, err)
+	}
+
+	for _, pattern := range c.Logs.Redact {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid log redaction pattern '%v' : %v", pattern, err)
+		}
+	}
+
+	if c.Backfill != "" && c.Backfill != BackfillReport && c.Backfill != BackfillSeed {
+		return fmt.Errorf("invalid backfill mode '%v', it must be '%v' or '%v'", c.Backfill, BackfillReport, BackfillSeed)
+	}
+
+	if c.Store.Type != "" && c.Store.Type != StoreFile && c.Store.Type != StoreConfigMap {
+		return fmt.Errorf("invalid store type '%v', it must be '%v' or '%v'", c.Store.Type, StoreFile, StoreConfigMap)
+	}
+
+	if c.Store.Type == StoreFile && c.Store.Path == "" {
+		return fmt.Errorf("the file store needs a path")
+	}
+
+	if g := c.Grouping; g.Window < 0 || (g.Window > 0 && g.MaxWait < g.Window) {
+		return fmt.Errorf("invalid grouping window %v, it must not be negative or greater than the max wait (%v)", g.Window, g.MaxWait)
+	}
+
+	for _, silence := range c.Silences {
+		if err := silence.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, severity := range []string{c.Severity.Default, c.Severity.Minimum} {
+		if severity != "" && !validSeverity(severity) {
+			return fmt.Errorf("invalid severity '%v', it must be '%v', '%v' or '%v'", severity, SeverityCritical, SeverityWarning, SeverityInfo)
+		}
+	}
+
+	for _, rule := range c.Severity.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for severity := range c.Severity.Channels {
+		if !validSeverity(severity) {
+			return fmt.Errorf("invalid severity '%v' in the severity channels, it must be '%v', '%v' or '%v'", severity, SeverityCritical, SeverityWarning, SeverityInfo)
+		}
+	}
+
+	for _, policy := range c.Escalations {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Digest.Period != "" && c.Digest.Period != DigestHourly && c.Digest.Period != DigestDaily {
+		return fmt.Errorf("invalid digest period '%v', it must be '%v' or '%v'", c.Digest.Period, DigestHourly, DigestDaily)
+	}
+
+	if c.Digest.Hour < 0 || c.Digest.Hour > 23 || c.Digest.Top < 0 {
+		return fmt.Errorf("invalid digest hour %v or top %v, the hour must be from 0 to 23 and the top must not be negative", c.Digest.Hour, c.Digest.Top)
+	}
+
+	if le := c.LeaderElection; le.Enabled && (le.LeaseDuration <= le.RenewDeadline || le.RenewDeadline <= le.RetryPeriod || le.RetryPeriod <= 0) {
+		return fmt.Errorf("invalid leader election durations, the lease duration (%v) must be greater than the renew deadline (%v) which must be greater than the retry period (%v)",
+			le.LeaseDuration, le.RenewDeadline, le.RetryPeriod)
+	}
+
+	return nil
+}
+
+// HandlerConfig returns the config that a handler for the notifications n is initialized with, it is 'c' with n as its notifications.
+// The slack title, user and icon and the custom event title are taken from 'c' when n does not set them. It is used for the handlers of
+// the escalation steps and the digest.
+func (c *Config) HandlerConfig(n NotificationConfig) *Config {
+
+	config := *c
+	config.Notification = n
+
+	if config.Notification.SlackTitle == "" {
+		config.Notification.SlackTitle = c.Notification.SlackTitle
+	}
+	if config.Notification.SlackUser == "" {
+		config.Notification.SlackUser = c.Notification.SlackUser
+	}
+	if config.Notification.SlackIcon == "" {
+		config.Notification.SlackIcon = c.Notification.SlackIcon
+	}
+	if config.Notification.CustomEventTitle == "" {
+		config.Notification.CustomEventTitle = c.Notification.CustomEventTitle
+	}
+
+	return &config
+}
+
+// WatchedNamespaces returns the namespaces that a watch should be created for. If AllNamespaces is set or include patterns are
+// present a single cluster wide watch is used, represented by an empty string (meta_v1.NamespaceAll).
+func (c *Config) WatchedNamespaces() []string {
+
+	if c.AllNamespaces || len(c.NamespaceInclude) > 0 {
+		return []string{""}
+	}
+
+	namespaces := []string{}
+	seen := map[string]bool{}
+	for _, ns := range append([]string{c.Namespace}, c.Namespaces...) {
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+
+	return namespaces
+}
+
+// NamespaceMatch returns true if notifications should be generated for namespace 'ns'. A namespace matching any of the
+// NamespaceExclude patterns is skipped, and when NamespaceInclude patterns are present the namespace must match one of them.
+func (c *Config) NamespaceMatch(ns string) bool {
+
+	for _, pattern := range c.NamespaceExclude {
+		if ok, _ := path.Match(pattern, ns); ok {
+			return false
+		}
+	}
+
+	if len(c.NamespaceInclude) == 0 {
+		return true
+	}
+
+	for _, pattern := range c.NamespaceInclude {
+		if ok, _ := path.Match(pattern, ns); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventReasonMatch returns true if a Warning event with the given reason should generate a notification.
+func (c *Config) EventReasonMatch(reason string) bool {
+
+	reasons := c.Events.Reasons
+	if len(reasons) == 0 {
+		reasons = DefaultEventReasons
+	}
+
+	for _, r := range reasons {
+		if r == "*" || strings.EqualFold(r, reason) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LabelMatch returns true if a pod with the labels 'l' is in the scope of the Labels selector, it is used for the pods of the
+// Warning events as the event watch can not be filtered on the labels of the pod.
+func (c *Config) LabelMatch(l map[string]string) bool {
+
+	if c.Labels == "" {
+		return true
+	}
+
+	selector, err := labels.Parse(c.Labels)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(l))
+}
+
+// LogRedactor returns a Redactor that replaces everything matching the Logs.Redact patterns with [REDACTED].
+// Patterns that do not compile are skipped, Validate() reports them.
+func (c *Config) LogRedactor() Redactor {
+
+	patterns := []*regexp.Regexp{}
+	for _, pattern := range c.Logs.Redact {
+		if re, err := regexp.Compile(pattern); err == nil {
+			patterns = append(patterns, re)
+		}
+	}
+
+	return func(logs string) string {
+		for _, re := range patterns {
+			logs = re.ReplaceAllString(logs, "[REDACTED]")
+		}
+		return logs
+	}
+}
+
+// splitList splits a comma seperated enviroment variable into a slice, dropping any empty entries.
+func splitList(value string) []string {
+
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
 }
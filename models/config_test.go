@@ -151,6 +151,8 @@ func TestVarLoad(t *testing.T) {
 		TIMECHECK string
 		DEBUG     string
 		STDOUT    string
+		LABELS    string
+		FIELDS    string
 	}{
 		"Config should be populated with values derived from ENV variables #1": {
 			CHANNEL:   "#kubes",
@@ -163,6 +165,8 @@ func TestVarLoad(t *testing.T) {
 			TIMECHECK: "8",
 			DEBUG:     "true",
 			STDOUT:    "true",
+			LABELS:    "tier=backend",
+			FIELDS:    "spec.nodeName=node-1",
 		},
 		"Config should be populated with values derived from ENV variables #2": {
 			CHANNEL:   "#bub-Tub",
@@ -258,8 +262,748 @@ func TestVarLoad(t *testing.T) {
 		if c.TimeCheck != timeEnv {
 			t.Errorf("Expected the TimeCheck value in the config to match the testcase value '%v' but received '%v'", timeEnv, c.TimeCheck)
 		}
+		if c.Labels != envVariables["LABELS"] {
+			t.Errorf("Expected the Labels value in the config to match the testcase value '%v' but received '%v'", envVariables["LABELS"], c.Labels)
+		}
+		if c.Fields != envVariables["FIELDS"] {
+			t.Errorf("Expected the Fields value in the config to match the testcase value '%v' but received '%v'", envVariables["FIELDS"], c.Fields)
+		}
 		if c.Debug != debug {
 			t.Errorf("Expected the Debug value in the config to match the testcase value '%v' but received '%v'", debug, c.Debug)
 		}
 	}
 }
+
+// TestNamespaces tests the Validate(), WatchedNamespaces() and NamespaceMatch() methods on Config that decide which namespaces
+// are watched and which of them generate notifications.
+func TestNamespaces(t *testing.T) {
+
+	testSuite := map[string]struct {
+		// Values for the config
+		namespace     string
+		namespaces    []string
+		allNamespaces bool
+		include       []string
+		exclude       []string
+		// The expected results
+		expectedWatch []string
+		matches       []string
+		noMatches     []string
+		expectError   bool
+	}{
+		"A single namespace should result in a single watch": {
+			namespace:     "hubbub",
+			expectedWatch: []string{"hubbub"},
+			matches:       []string{"hubbub"},
+		},
+		"Namespace and Namespaces should be merged without duplicates": {
+			namespace:     "hubbub",
+			namespaces:    []string{"payments", "hubbub", "orders"},
+			expectedWatch: []string{"hubbub", "payments", "orders"},
+		},
+		"AllNamespaces should result in a cluster wide watch": {
+			namespace:     "hubbub",
+			allNamespaces: true,
+			expectedWatch: []string{""},
+			matches:       []string{"hubbub", "kube-system"},
+		},
+		"Include patterns should result in a cluster wide watch that is filtered": {
+			include:       []string{"team-*", "payments"},
+			exclude:       []string{"team-sandbox*"},
+			expectedWatch: []string{""},
+			matches:       []string{"team-orders", "payments"},
+			noMatches:     []string{"kube-system", "team-sandbox-1", "payments-dev"},
+		},
+		"Exclude patterns should filter a cluster wide watch": {
+			allNamespaces: true,
+			exclude:       []string{"kube-*"},
+			expectedWatch: []string{""},
+			matches:       []string{"default"},
+			noMatches:     []string{"kube-system", "kube-public"},
+		},
+		"A config without any namespaces should fail validation": {
+			expectedWatch: []string{},
+			expectError:   true,
+		},
+		"A malformed pattern should fail validation": {
+			allNamespaces: true,
+			exclude:       []string{"team-["},
+			expectedWatch: []string{""},
+			expectError:   true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{
+			Namespace:        testCase.namespace,
+			Namespaces:       testCase.namespaces,
+			AllNamespaces:    testCase.allNamespaces,
+			NamespaceInclude: testCase.include,
+			NamespaceExclude: testCase.exclude,
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+
+		if watched := c.WatchedNamespaces(); !reflect.DeepEqual(watched, testCase.expectedWatch) {
+			t.Errorf("Expected WatchedNamespaces() to return %v but received %v", testCase.expectedWatch, watched)
+		}
+
+		for _, ns := range testCase.matches {
+			if !c.NamespaceMatch(ns) {
+				t.Errorf("Expected the namespace %v to match", ns)
+			}
+		}
+
+		for _, ns := range testCase.noMatches {
+			if c.NamespaceMatch(ns) {
+				t.Errorf("Expected the namespace %v not to match", ns)
+			}
+		}
+	}
+
+}
+
+// TestSelectors tests that Validate() rejects label and field selectors that the API server would not accept.
+func TestSelectors(t *testing.T) {
+
+	testSuite := map[string]struct {
+		labels      string
+		fields      string
+		expectError bool
+	}{
+		"Empty selectors should be valid": {},
+		"Set based label selectors should be valid": {
+			labels: "tier=backend,!job-name,environment in (prod, staging)",
+		},
+		"Field selectors should be valid": {
+			fields: "spec.nodeName=node-1,status.phase!=Succeeded",
+		},
+		"A malformed label selector should fail validation": {
+			labels:      "tier==,=backend",
+			expectError: true,
+		},
+		"A malformed field selector should fail validation": {
+			fields:      "spec.nodeName",
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub", Labels: testCase.labels, Fields: testCase.fields}
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestLabelMatch tests the LabelMatch() method on Config which scopes the pods of the Warning events to the label selector.
+func TestLabelMatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		selector string
+		labels   map[string]string
+		expected bool
+	}{
+		"Every pod should match without a selector": {
+			labels:   map[string]string{"tier": "frontend"},
+			expected: true,
+		},
+		"A pod with matching labels should match": {
+			selector: "tier=backend,!job-name",
+			labels:   map[string]string{"tier": "backend"},
+			expected: true,
+		},
+		"A pod with other labels should not match": {
+			selector: "tier=backend",
+			labels:   map[string]string{"tier": "frontend"},
+		},
+		"A pod whose labels are not known should not match": {
+			selector: "tier=backend",
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Labels: testCase.selector}
+		if ok := c.LabelMatch(testCase.labels); ok != testCase.expected {
+			t.Errorf("expected %v but received %v", testCase.expected, ok)
+		}
+	}
+
+}
+
+// TestEventReasonMatch tests the EventReasonMatch() method on Config which filters the Warning events that generate notifications.
+func TestEventReasonMatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		reasons   []string
+		matches   []string
+		noMatches []string
+	}{
+		"The default reasons should be used when none are configured": {
+			matches:   []string{"FailedScheduling", "FailedMount", "BackOff", "FailedCreatePodSandBox", "Unhealthy"},
+			noMatches: []string{"NodeNotReady", "Pulled"},
+		},
+		"Only the configured reasons should match": {
+			reasons:   []string{"FailedMount", "unhealthy"},
+			matches:   []string{"FailedMount", "Unhealthy"},
+			noMatches: []string{"FailedScheduling", "BackOff"},
+		},
+		"A wildcard should match every reason": {
+			reasons: []string{"*"},
+			matches: []string{"FailedMount", "NodeNotReady", "Evicted"},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{}
+		c.Events.Reasons = testCase.reasons
+
+		for _, reason := range testCase.matches {
+			if !c.EventReasonMatch(reason) {
+				t.Errorf("Expected the reason %v to match", reason)
+			}
+		}
+
+		for _, reason := range testCase.noMatches {
+			if c.EventReasonMatch(reason) {
+				t.Errorf("Expected the reason %v not to match", reason)
+			}
+		}
+	}
+
+}
+
+// TestLogRedactor tests the LogRedactor() method on Config which scrubs the container logs, and the validation of its patterns.
+func TestLogRedactor(t *testing.T) {
+
+	testSuite := map[string]struct {
+		patterns    []string
+		logs        string
+		expected    string
+		expectError bool
+	}{
+		"Logs should be unchanged without any patterns": {
+			logs:     "connecting to db password=hunter2",
+			expected: "connecting to db password=hunter2",
+		},
+		"Every match of every pattern should be redacted": {
+			patterns: []string{`password=\S+`, `Bearer [A-Za-z0-9.]+`},
+			logs:     "password=hunter2 failed\nAuthorization: Bearer abc.def then password=hunter3",
+			expected: "[REDACTED] failed\nAuthorization: [REDACTED] then [REDACTED]",
+		},
+		"An invalid pattern should fail validation": {
+			patterns:    []string{`password=(\S+`},
+			logs:        "password=hunter2",
+			expected:    "password=hunter2",
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub"}
+		c.Logs.Redact = testCase.patterns
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+
+		if logs := c.LogRedactor()(testCase.logs); logs != testCase.expected {
+			t.Errorf("expected %v but received %v", testCase.expected, logs)
+		}
+	}
+
+}
+
+// TestLoadEnvVarsJSON tests the env variables that hold json, the redaction patterns must keep their commas and a malformed
+// value must be returned as an error by LoadEnvVars() rather than ignored.
+func TestLoadEnvVarsJSON(t *testing.T) {
+
+	testSuite := map[string]struct {
+		env            string
+		value          string
+		expectedRedact []string
+		expectError    bool
+	}{
+		"Redaction patterns containing commas should be kept whole": {
+			env:            "HUBBUB_LOG_REDACT",
+			value:          `["\\d{3,4}", "[a-z,]+"]`,
+			expectedRedact: []string{`\d{3,4}`, `[a-z,]+`},
+		},
+		"Redaction patterns that are not a json array should return an error": {
+			env:         "HUBBUB_LOG_REDACT",
+			value:       `\d{3,4}`,
+			expectError: true,
+		},
+		"Malformed silences should return an error": {
+			env:         "HUBBUB_SILENCES",
+			value:       `[{"matchers":`,
+			expectError: true,
+		},
+		"Malformed severity rules should return an error": {
+			env:         "HUBBUB_SEVERITY_RULES",
+			value:       `{"severity":"info"}`,
+			expectError: true,
+		},
+		"Malformed escalations should return an error": {
+			env:         "HUBBUB_ESCALATIONS",
+			value:       `pager`,
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		os.Setenv(testCase.env, testCase.value)
+		c := Config{Namespace: "hubbub"}
+		err := c.LoadEnvVars()
+		os.Unsetenv(testCase.env)
+
+		if (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from LoadEnvVars() : %v, but received %v", testCase.expectError, err)
+		}
+
+		if testCase.expectedRedact != nil {
+			if !reflect.DeepEqual(c.Logs.Redact, testCase.expectedRedact) {
+				t.Errorf("Expected the patterns %v but received %v", testCase.expectedRedact, c.Logs.Redact)
+			}
+			if err := c.Validate(); err != nil {
+				t.Errorf("Expected the patterns to be valid but received %v", err)
+			}
+		}
+	}
+
+}
+
+// TestLeaderElection tests the leader election defaults set by LoadEnvVars() and the ordering of the durations checked by Validate().
+func TestLeaderElection(t *testing.T) {
+
+	testSuite := map[string]struct {
+		enabled       bool
+		leaseDuration int
+		renewDeadline int
+		retryPeriod   int
+		expectError   bool
+	}{
+		"The defaults should be valid": {
+			enabled: true,
+		},
+		"A renew deadline longer than the lease should fail validation": {
+			enabled:       true,
+			leaseDuration: 10,
+			renewDeadline: 15,
+			expectError:   true,
+		},
+		"A retry period equal to the renew deadline should fail validation": {
+			enabled:       true,
+			renewDeadline: 5,
+			retryPeriod:   5,
+			expectError:   true,
+		},
+		"The durations should not be checked when leader election is disabled": {
+			leaseDuration: 10,
+			renewDeadline: 15,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub"}
+		c.LeaderElection.Enabled = testCase.enabled
+		c.LeaderElection.LeaseDuration = testCase.leaseDuration
+		c.LeaderElection.RenewDeadline = testCase.renewDeadline
+		c.LeaderElection.RetryPeriod = testCase.retryPeriod
+		c.LoadEnvVars()
+
+		if c.LeaderElection.LeaseName != DefaultLeaseName {
+			t.Errorf("Expected the lease name %v but received %v", DefaultLeaseName, c.LeaderElection.LeaseName)
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestBackfillMode tests that Validate() only accepts the known backfill modes.
+func TestBackfillMode(t *testing.T) {
+
+	testSuite := map[string]struct {
+		mode        string
+		expectError bool
+	}{
+		"No mode should be valid":              {},
+		"The report mode should be valid":      {mode: "report"},
+		"The mode should not be case senstive": {mode: "Seed"},
+		"An unknown mode should fail validation": {
+			mode:        "replay",
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub", Backfill: testCase.mode}
+		c.LoadEnvVars()
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestStore tests that Validate() only accepts the known store types and that the file store has a path.
+func TestStore(t *testing.T) {
+
+	testSuite := map[string]struct {
+		storeType   string
+		path        string
+		expectError bool
+	}{
+		"No store should be valid":                   {},
+		"The ConfigMap store should be valid":        {storeType: "configmap"},
+		"The file store with a path should be valid": {storeType: "File", path: "/var/lib/hubbub/state.json"},
+		"The file store without a path should fail":  {storeType: "file", expectError: true},
+		"An unknown store should fail validation":    {storeType: "bolt", expectError: true},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub"}
+		c.Store.Type = testCase.storeType
+		c.Store.Path = testCase.path
+		c.LoadEnvVars()
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+
+		if c.Store.ConfigMap != DefaultStoreConfigMap {
+			t.Errorf("Expected the store ConfigMap %v but received %v", DefaultStoreConfigMap, c.Store.ConfigMap)
+		}
+	}
+
+}
+
+// TestGrouping tests that the grouping max wait defaults once a window is set and that Validate() rejects a window greater than
+// the max wait.
+func TestGrouping(t *testing.T) {
+
+	testSuite := map[string]struct {
+		window          int
+		maxWait         int
+		expectedMaxWait int
+		expectError     bool
+	}{
+		"No window should leave grouping disabled": {},
+		"A window without a max wait should use the default": {
+			window:          30,
+			expectedMaxWait: DefaultGroupMaxWait,
+		},
+		"A window within the max wait should be valid": {
+			window:          30,
+			maxWait:         120,
+			expectedMaxWait: 120,
+		},
+		"A window greater than the max wait should fail validation": {
+			window:          90,
+			maxWait:         60,
+			expectedMaxWait: 60,
+			expectError:     true,
+		},
+		"A negative window should fail validation": {
+			window:      -1,
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{Namespace: "hubbub"}
+		c.Grouping.Window = testCase.window
+		c.Grouping.MaxWait = testCase.maxWait
+		c.LoadEnvVars()
+
+		if c.Grouping.MaxWait != testCase.expectedMaxWait {
+			t.Errorf("Expected the max wait %v but received %v", testCase.expectedMaxWait, c.Grouping.MaxWait)
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestSeverity tests loading the severity config from the enviroment variables and that Validate() only accepts the known severities.
+func TestSeverity(t *testing.T) {
+
+	testSuite := map[string]struct {
+		env              map[string]string
+		rules            []SeverityRule
+		expectedDefault  string
+		expectedRules    int
+		expectedChannels map[string]string
+		expectError      bool
+	}{
+		"No severity config should be valid": {
+			expectedChannels: map[string]string{},
+		},
+		"The severity should be loaded from the enviroment variables": {
+			env: map[string]string{
+				"HUBBUB_SEVERITY_DEFAULT":  "Warning",
+				"HUBBUB_SEVERITY_MINIMUM":  "info",
+				"HUBBUB_SEVERITY_RULES":    `[{"severity":"info","exitCodes":[143]},{"severity":"critical","reasons":["OOMKilled"]}]`,
+				"HUBBUB_SEVERITY_CHANNELS": "Critical=#oncall, info=#noise",
+			},
+			expectedDefault:  SeverityWarning,
+			expectedRules:    2,
+			expectedChannels: map[string]string{SeverityCritical: "#oncall", SeverityInfo: "#noise"},
+		},
+		"An unknown default severity should fail validation": {
+			env:              map[string]string{"HUBBUB_SEVERITY_DEFAULT": "high"},
+			expectedDefault:  "high",
+			expectedChannels: map[string]string{},
+			expectError:      true,
+		},
+		"A rule with an unknown severity should fail validation": {
+			rules:            []SeverityRule{{Severity: "page"}},
+			expectedRules:    1,
+			expectedChannels: map[string]string{},
+			expectError:      true,
+		},
+		"A rule with an invalid label selector should fail validation": {
+			rules:            []SeverityRule{{Severity: SeverityInfo, Labels: "tier in (batch"}},
+			expectedRules:    1,
+			expectedChannels: map[string]string{},
+			expectError:      true,
+		},
+		"A channel for an unknown severity should fail validation": {
+			env:              map[string]string{"HUBBUB_SEVERITY_CHANNELS": "page=#oncall"},
+			expectedChannels: map[string]string{"page": "#oncall"},
+			expectError:      true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		for k, v := range testCase.env {
+			os.Setenv(k, v)
+		}
+
+		c := Config{Namespace: "hubbub"}
+		c.Severity.Rules = testCase.rules
+		c.LoadEnvVars()
+
+		for k := range testCase.env {
+			os.Unsetenv(k)
+		}
+
+		if c.Severity.Default != testCase.expectedDefault {
+			t.Errorf("Expected the default severity %v but received %v", testCase.expectedDefault, c.Severity.Default)
+		}
+
+		if len(c.Severity.Rules) != testCase.expectedRules {
+			t.Errorf("Expected %v severity rules but received %v", testCase.expectedRules, len(c.Severity.Rules))
+		}
+
+		if !reflect.DeepEqual(c.Severity.Channels, testCase.expectedChannels) {
+			t.Errorf("Expected the severity channels %v but received %v", testCase.expectedChannels, c.Severity.Channels)
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestEscalations tests loading the escalation policies from the enviroment variable, that a policy without a name is named after
+// its position and that Validate() checks each policy.
+func TestEscalations(t *testing.T) {
+
+	testSuite := map[string]struct {
+		env           string
+		policies      []EscalationPolicy
+		expectedNames []string
+		expectError   bool
+	}{
+		"No escalations should be valid": {},
+		"The escalations should be loaded from the enviroment variable": {
+			env:           `[{"name":"pager","severities":["Critical"],"steps":[{"after":600,"notifications":{"type":"Webhook","webhookUrl":"https://events.pager.local"}}]}]`,
+			expectedNames: []string{"pager"},
+		},
+		"A policy without a name should be named after its position": {
+			policies:      []EscalationPolicy{{Steps: []EscalationStep{{After: 600}}}, {Steps: []EscalationStep{{After: 300}}}},
+			expectedNames: []string{"escalation-1", "escalation-2"},
+		},
+		"A policy without steps should fail validation": {
+			policies:      []EscalationPolicy{{Name: "pager"}},
+			expectedNames: []string{"pager"},
+			expectError:   true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if testCase.env != "" {
+			os.Setenv("HUBBUB_ESCALATIONS", testCase.env)
+		}
+
+		c := Config{Namespace: "hubbub"}
+		c.Escalations = testCase.policies
+		c.LoadEnvVars()
+		os.Unsetenv("HUBBUB_ESCALATIONS")
+
+		names := []string{}
+		for _, policy := range c.Escalations {
+			names = append(names, policy.Name)
+		}
+
+		if len(names) != len(testCase.expectedNames) || (len(names) > 0 && !reflect.DeepEqual(names, testCase.expectedNames)) {
+			t.Errorf("Expected the escalation policies %v but received %v", testCase.expectedNames, names)
+		}
+
+		if testCase.env != "" && (c.Escalations[0].Severities[0] != SeverityCritical || c.Escalations[0].Steps[0].Notification.Handler != "webhook") {
+			t.Errorf("Expected the severities and handler types to be lower case but received %v and %v", c.Escalations[0].Severities,
+				c.Escalations[0].Steps[0].Notification.Handler)
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
+
+// TestHandlerConfig tests that the handler of an escalation step uses the notifications of the step, with the slack title, user and
+// icon of the config when the step has none.
+func TestHandlerConfig(t *testing.T) {
+
+	c := Config{Namespace: "hubbub"}
+	c.Notification = NotificationConfig{Handler: "slack", SlackWebHook: "https://hooks.slack.local/a", SlackChannel: "#alerts", SlackTitle: "Hubbub",
+		SlackUser: "hubbub"}
+
+	step := EscalationStep{After: 600, Notification: NotificationConfig{Handler: "slack", SlackWebHook: "https://hooks.slack.local/b",
+		SlackChannel: "#oncall", SlackUser: "pager"}}
+
+	config := c.HandlerConfig(step.Notification)
+
+	if config.Notification.SlackWebHook != step.Notification.SlackWebHook || config.Notification.SlackChannel != "#oncall" {
+		t.Errorf("Expected the webhook and channel of the step but received %v and %v", config.Notification.SlackWebHook, config.Notification.SlackChannel)
+	}
+
+	if config.Notification.SlackTitle != "Hubbub" || config.Notification.SlackUser != "pager" {
+		t.Errorf("Expected the title of the config and the user of the step but received %v and %v", config.Notification.SlackTitle,
+			config.Notification.SlackUser)
+	}
+
+	if c.Notification.SlackChannel != "#alerts" {
+		t.Errorf("Expected the config to be left as is but its channel is %v", c.Notification.SlackChannel)
+	}
+}
+
+// TestDigest tests that the digest settings are loaded from the enviroment variables and validated.
+func TestDigest(t *testing.T) {
+
+	testSuite := map[string]struct {
+		env            map[string]string
+		period         string
+		hour           int
+		expectedPeriod string
+		expectedHour   int
+		expectedTop    int
+		expectError    bool
+	}{
+		"No digest should be valid": {
+			expectedTop: DefaultDigestTop,
+		},
+		"The digest should be loaded from the enviroment variables": {
+			env:            map[string]string{"HUBBUB_DIGEST": "Daily", "HUBBUB_DIGEST_HOUR": "8", "HUBBUB_DIGEST_TOP": "10"},
+			expectedPeriod: DigestDaily,
+			expectedHour:   8,
+			expectedTop:    10,
+		},
+		"The config should take precedence over the enviroment variables": {
+			env:            map[string]string{"HUBBUB_DIGEST": "daily"},
+			period:         "hourly",
+			expectedPeriod: DigestHourly,
+			expectedTop:    DefaultDigestTop,
+		},
+		"An unknown period should fail validation": {
+			period:         "weekly",
+			expectedPeriod: "weekly",
+			expectedTop:    DefaultDigestTop,
+			expectError:    true,
+		},
+		"An hour past 23 should fail validation": {
+			period:         "daily",
+			hour:           24,
+			expectedPeriod: DigestDaily,
+			expectedHour:   24,
+			expectedTop:    DefaultDigestTop,
+			expectError:    true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		for k, v := range testCase.env {
+			os.Setenv(k, v)
+		}
+
+		c := Config{Namespace: "hubbub"}
+		c.Digest.Period = testCase.period
+		c.Digest.Hour = testCase.hour
+		c.LoadEnvVars()
+
+		for k := range testCase.env {
+			os.Unsetenv(k)
+		}
+
+		if c.Digest.Period != testCase.expectedPeriod || c.Digest.Hour != testCase.expectedHour || c.Digest.Top != testCase.expectedTop {
+			t.Errorf("Expected the period %v, hour %v and top %v but received %v, %v and %v", testCase.expectedPeriod, testCase.expectedHour,
+				testCase.expectedTop, c.Digest.Period, c.Digest.Hour, c.Digest.Top)
+		}
+
+		if err := c.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The digest periods. An hourly digest is sent at the start of every hour, a daily digest at the hour set in the config.
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// DefaultDigestTop is the number of namespaces, workloads, reasons, exit codes and pods listed in a digest when the config does not set it.
+const DefaultDigestTop = 5
+
+// Digest counts the failures seen from Start until End, see Add(). Namespaces, Workloads, Reasons, ExitCodes and Pods are the number
+// of failures for each of them, Unresolved are the incidents that are still open when the digest is sent.
+type Digest struct {
+	Start      time.Time
+	End        time.Time
+	Failures   int
+	Namespaces map[string]int
+	Workloads  map[string]int
+	Reasons    map[string]int
+	ExitCodes  map[string]int
+	Pods       map[string]int
+	Unresolved []Incident
+}
+
+// DigestCount is the number of failures for a namespace, workload, reason, exit code or pod in a digest.
+type DigestCount struct {
+	Name  string
+	Count int
+}
+
+// NewDigest returns an empty Digest that starts at start.
+func NewDigest(start time.Time) *Digest {
+
+	return &Digest{
+		Start:      start,
+		Namespaces: map[string]int{},
+		Workloads:  map[string]int{},
+		Reasons:    map[string]int{},
+		ExitCodes:  map[string]int{},
+		Pods:       map[string]int{},
+	}
+}
+
+// Add counts each of the failures in p. A workload is counted in the form namespace/Kind/Name and a pod in the form namespace/name,
+// the exit code is only counted for a container that exited.
+func (d *Digest) Add(p PodStatusInformation) {
+
+	for _, f := range p.Failures {
+		d.Failures++
+		d.Namespaces[p.Namespace]++
+		d.Workloads[p.IncidentKey()]++
+		d.Reasons[f.Cause()]++
+		d.Pods[p.Namespace+"/"+p.PodName]++
+		if f.ExitCode != 0 {
+			d.ExitCodes[strconv.Itoa(f.ExitCode)]++
+		}
+	}
+}
+
+// TopCounts returns the n entries of counts with the most failures, the most first. Entries with the same count are ordered by name.
+func TopCounts(counts map[string]int, n int) []DigestCount {
+
+	top := make([]DigestCount, 0, len(counts))
+	for name, count := range counts {
+		top = append(top, DigestCount{Name: name, Count: count})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Name < top[j].Name
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// digestReport is the json layout of a digest, the counts are cut down to the top entries.
+type digestReport struct {
+	Summary    string
+	Start      time.Time
+	End        time.Time
+	Failures   int
+	Namespaces []DigestCount
+	Workloads  []DigestCount
+	Reasons    []DigestCount
+	ExitCodes  []DigestCount
+	Pods       []DigestCount
+	Unresolved []Incident
+}
+
+// BuildDigestBody builds the notification for a digest, each list is cut down to its top entries. Slack receives a message with a
+// section for each list and the unresolved incidents, the other handlers receive the digest as json and each list as json in its own
+// property.
+func BuildDigestBody(handler NotificationHandler, d Digest, top int) (NotificationDetails, error) {
+
+	nDetails := NotificationDetails{}
+
+	if s, ok := handler.(*Slack); ok {
+		var err error
+		nDetails.body, err = BuildSlackDigestBody(s, d, top)
+		if err != nil {
+			return nDetails, err
+		}
+		return nDetails, nil
+	}
+
+	report := digestReport{
+		Summary:    digestSummary(d),
+		Start:      d.Start,
+		End:        d.End,
+		Failures:   d.Failures,
+		Namespaces: TopCounts(d.Namespaces, top),
+		Workloads:  TopCounts(d.Workloads, top),
+		Reasons:    TopCounts(d.Reasons, top),
+		ExitCodes:  TopCounts(d.ExitCodes, top),
+		Pods:       TopCounts(d.Pods, top),
+		Unresolved: d.Unresolved,
+	}
+	if report.Unresolved == nil {
+		report.Unresolved = []Incident{}
+	}
+
+	nDetails.body, _ = json.Marshal(report)
+
+	namespaces, _ := json.Marshal(report.Namespaces)
+	workloads, _ := json.Marshal(report.Workloads)
+	reasons, _ := json.Marshal(report.Reasons)
+	exitCodes, _ := json.Marshal(report.ExitCodes)
+	pods, _ := json.Marshal(report.Pods)
+	unresolved, _ := json.Marshal(report.Unresolved)
+
+	nDetails.properties = map[string]string{
+		"Status":          "Digest",
+		"Summary":         report.Summary,
+		"Start":           d.Start.String(),
+		"End":             d.End.String(),
+		"Failures":        strconv.Itoa(d.Failures),
+		"Namespaces":      string(namespaces),
+		"Workloads":       string(workloads),
+		"Reasons":         string(reasons),
+		"ExitCodes":       string(exitCodes),
+		"Pods":            string(pods),
+		"UnresolvedCount": strconv.Itoa(len(d.Unresolved)),
+		"Unresolved":      string(unresolved),
+	}
+
+	return nDetails, nil
+}
+
+// BuildSlackDigestBody builds the slack payload for BuildDigestBody. The message is green when there were no failures and nothing is
+// unresolved.
+func BuildSlackDigestBody(s *Slack, d Digest, top int) ([]byte, error) {
+
+	msg := fmt.Sprintf("*%v*", digestSummary(d))
+
+	sections := []struct {
+		title  string
+		counts map[string]int
+		quote  bool
+	}{
+		{title: "Namespaces", counts: d.Namespaces},
+		{title: "Workloads", counts: d.Workloads},
+		{title: "Top reasons", counts: d.Reasons, quote: true},
+		{title: "Top exit codes", counts: d.ExitCodes, quote: true},
+		{title: "Noisiest pods", counts: d.Pods},
+	}
+
+	for _, section := range sections {
+
+		if len(section.counts) == 0 {
+			continue
+		}
+
+		msg += fmt.Sprintf("\n\n*%v*", section.title)
+		for _, c := range TopCounts(section.counts, top) {
+			name := c.Name
+			if section.quote {
+				name = "`" + name + "`"
+			}
+			msg += fmt.Sprintf("\n> %v : *%v*", name, c.Count)
+		}
+	}
+
+	if len(d.Unresolved) > 0 {
+		msg += fmt.Sprintf("\n\n*Unresolved (%v)*", len(d.Unresolved))
+		for _, i := range d.Unresolved {
+
+			kind, name := i.Workload, ""
+			if parts := strings.SplitN(i.Workload, "/", 2); len(parts) == 2 {
+				kind, name = parts[0], parts[1]
+			}
+
+			msg += fmt.Sprintf("\n> %v *%v* in namespace *%v* has been failing for *%v* with : `%v`", kind, name, i.Namespace,
+				formatWindow(d.End.Sub(i.Opened)), strings.Join(i.Causes, "`, `"))
+		}
+	}
+
+	color := "warning"
+	if d.Failures == 0 && len(d.Unresolved) == 0 {
+		color = "good"
+	}
+
+	body := *s
+	body.Attachment = []SlackAttachments{
+		SlackAttachments{
+			Fallback: msg,
+			Color:    color,
+			Title:    s.Title,
+			Field:    []SlackFields{SlackFields{Value: msg}},
+		},
+	}
+
+	slackMsg, _ := json.Marshal(body)
+
+	return slackMsg, nil
+}
+
+// digestSummary returns the first line of a digest, e.g. "42 failure(s) from Dec 12 09:00:00 until Dec 12 10:00:00".
+func digestSummary(d Digest) string {
+
+	if d.Failures == 0 {
+		return fmt.Sprintf("No failures from %v until %v", d.Start.Format(time.Stamp), d.End.Format(time.Stamp))
+	}
+
+	return fmt.Sprintf("%v failure(s) from %v until %v", d.Failures, d.Start.Format(time.Stamp), d.End.Format(time.Stamp))
+}
+
+// NextDigest returns the time the digest for the period that includes 'now' is sent, the start of the next hour for an hourly digest
+// and the next time it is hour o'clock for a daily digest. The hour is in the location of 'now'.
+func NextDigest(now time.Time, period string, hour int) time.Time {
+
+	if period == DigestDaily {
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, 0, 0, 0, now.Location())
+		}
+		return next
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDigestAdd tests that every failure is counted for its namespace, workload, reason and pod, and that only the exit codes of the
+// containers that exited are counted.
+func TestDigestAdd(t *testing.T) {
+
+	d := NewDigest(time.Now())
+	d.Add(PodStatusInformation{Namespace: "payments", PodName: "api-1", OwnerKind: "Deployment", OwnerName: "api",
+		Failures: []ContainerFailure{{ContainerName: "app", Reason: "OOMKilled", ExitCode: 137}, {ContainerName: "proxy", Reason: "ImagePullBackOff"}}})
+	d.Add(PodStatusInformation{Namespace: "payments", PodName: "api-2", OwnerKind: "Deployment", OwnerName: "api",
+		Failures: []ContainerFailure{{ContainerName: "app", Reason: "OOMKilled", ExitCode: 137}}})
+	d.Add(PodStatusInformation{Namespace: "orders", PodName: "worker", Failures: []ContainerFailure{{ContainerName: "app", Reason: "Error", ExitCode: 1}}})
+
+	testSuite := map[string]struct {
+		counts   map[string]int
+		expected map[string]int
+	}{
+		"The namespaces should be counted": {
+			counts:   d.Namespaces,
+			expected: map[string]int{"payments": 3, "orders": 1},
+		},
+		"The workloads should be counted by their incident key": {
+			counts:   d.Workloads,
+			expected: map[string]int{"payments/Deployment/api": 3, "orders/Pod/worker": 1},
+		},
+		"The reasons should be counted": {
+			counts:   d.Reasons,
+			expected: map[string]int{"OOMKilled": 2, "ImagePullBackOff": 1, "Error": 1},
+		},
+		"Only the exit codes of the containers that exited should be counted": {
+			counts:   d.ExitCodes,
+			expected: map[string]int{"137": 2, "1": 1},
+		},
+		"The pods should be counted": {
+			counts:   d.Pods,
+			expected: map[string]int{"payments/api-1": 2, "payments/api-2": 1, "orders/worker": 1},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if !reflect.DeepEqual(testCase.counts, testCase.expected) {
+			t.Errorf("Expected the counts %v but received %v", testCase.expected, testCase.counts)
+		}
+	}
+
+	if d.Failures != 4 {
+		t.Errorf("Expected 4 failures but received %v", d.Failures)
+	}
+
+}
+
+// TestTopCounts tests that the entries with the most failures are returned first, and those with the same count by name.
+func TestTopCounts(t *testing.T) {
+
+	counts := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+
+	testSuite := map[string]struct {
+		n        int
+		expected []DigestCount
+	}{
+		"The entries should be ordered by count then name": {
+			n:        10,
+			expected: []DigestCount{{"c", 5}, {"a", 2}, {"b", 2}, {"d", 1}},
+		},
+		"Only the top entries should be returned": {
+			n:        2,
+			expected: []DigestCount{{"c", 5}, {"a", 2}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if top := TopCounts(counts, testCase.n); !reflect.DeepEqual(top, testCase.expected) {
+			t.Errorf("Expected %v but received %v", testCase.expected, top)
+		}
+	}
+
+}
+
+// TestNextDigest tests that an hourly digest is sent at the start of the next hour and a daily digest at the next time it is the
+// hour of the config.
+func TestNextDigest(t *testing.T) {
+
+	now := time.Date(2019, time.December, 12, 9, 30, 0, 0, time.UTC)
+
+	testSuite := map[string]struct {
+		period   string
+		hour     int
+		now      time.Time
+		expected time.Time
+	}{
+		"An hourly digest should be sent at the start of the next hour": {
+			period:   DigestHourly,
+			now:      now,
+			expected: time.Date(2019, time.December, 12, 10, 0, 0, 0, time.UTC),
+		},
+		"A daily digest should be sent later today if the hour has not passed": {
+			period:   DigestDaily,
+			hour:     17,
+			now:      now,
+			expected: time.Date(2019, time.December, 12, 17, 0, 0, 0, time.UTC),
+		},
+		"A daily digest should be sent tomorrow if the hour has passed": {
+			period:   DigestDaily,
+			hour:     8,
+			now:      now,
+			expected: time.Date(2019, time.December, 13, 8, 0, 0, 0, time.UTC),
+		},
+		"A daily digest should not be sent twice at its hour": {
+			period:   DigestDaily,
+			hour:     9,
+			now:      time.Date(2019, time.December, 12, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2019, time.December, 13, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if next := NextDigest(testCase.now, testCase.period, testCase.hour); !next.Equal(testCase.expected) {
+			t.Errorf("Expected the digest at %v but received %v", testCase.expected, next)
+		}
+	}
+
+}
+
+// TestBuildDigestBody tests that the slack message lists the top entries and unresolved incidents, and that the other handlers
+// receive the digest as json.
+func TestBuildDigestBody(t *testing.T) {
+
+	start := time.Date(2019, time.December, 12, 9, 0, 0, 0, time.UTC)
+
+	failing := NewDigest(start)
+	failing.End = start.Add(time.Hour)
+	failing.Add(PodStatusInformation{Namespace: "payments", PodName: "api-1", OwnerKind: "Deployment", OwnerName: "api",
+		Failures: []ContainerFailure{{ContainerName: "app", Reason: "OOMKilled", ExitCode: 137}}})
+	failing.Add(PodStatusInformation{Namespace: "orders", PodName: "worker", Failures: []ContainerFailure{{ContainerName: "app", Reason: "Error", ExitCode: 1}}})
+	failing.Unresolved = []Incident{{Namespace: "payments", Workload: "Deployment/api", Causes: []string{"OOMKilled"}, Opened: start.Add(30 * time.Minute)}}
+
+	quiet := NewDigest(start)
+	quiet.End = start.Add(time.Hour)
+
+	testSuite := map[string]struct {
+		digest             *Digest
+		top                int
+		expectedStrings    []string
+		unexpectedStrings  []string
+		expectedColor      string
+		expectedProperties map[string]string
+	}{
+		"The top entries and unresolved incidents should be listed": {
+			digest: failing,
+			top:    1,
+			expectedStrings: []string{
+				"*2 failure(s) from Dec 12 09:00:00 until Dec 12 10:00:00*",
+				"*Namespaces*\n> orders : *1*",
+				"*Top reasons*\n> `Error` : *1*",
+				"*Top exit codes*\n> `1` : *1*",
+				"*Noisiest pods*\n> orders/worker : *1*",
+				"*Unresolved (1)*\n> Deployment *api* in namespace *payments* has been failing for *30m* with : `OOMKilled`",
+			},
+			unexpectedStrings: []string{"> payments : *1*"},
+			expectedColor:     "warning",
+			expectedProperties: map[string]string{
+				"Status":          "Digest",
+				"Failures":        "2",
+				"Namespaces":      `[{"Name":"orders","Count":1}]`,
+				"UnresolvedCount": "1",
+			},
+		},
+		"A digest without failures should say so": {
+			digest:            quiet,
+			top:               DefaultDigestTop,
+			expectedStrings:   []string{"*No failures from Dec 12 09:00:00 until Dec 12 10:00:00*"},
+			unexpectedStrings: []string{"*Namespaces*", "*Unresolved"},
+			expectedColor:     "good",
+			expectedProperties: map[string]string{
+				"Status":          "Digest",
+				"Failures":        "0",
+				"Namespaces":      "[]",
+				"Unresolved":      "[]",
+				"UnresolvedCount": "0",
+			},
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, err := BuildDigestBody(slack, *testCase.digest, testCase.top)
+		if err != nil {
+			t.Fatalf("Expected no error but received %v", err)
+		}
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		for _, unexpected := range testCase.unexpectedStrings {
+			if strings.Contains(msg, unexpected) {
+				t.Errorf("Expected the slack message not to contain %v.\n%v", unexpected, msg)
+			}
+		}
+
+		if slackBody.Attachment[0].Color != testCase.expectedColor {
+			t.Errorf("Expected the color %v but received %v", testCase.expectedColor, slackBody.Attachment[0].Color)
+		}
+
+		details, _ := BuildDigestBody(new(STDOUT), *testCase.digest, testCase.top)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+
+		report := digestReport{}
+		if err := json.Unmarshal(details.body, &report); err != nil || report.Failures != testCase.digest.Failures {
+			t.Errorf("Expected the body to be the digest as json but received %v : %v", string(details.body), err)
+		}
+	}
+
+}
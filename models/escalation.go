@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// EscalationPolicy escalates the incidents it matches that are still open after the delay of each of its Steps. Namespaces are glob
+// patterns and Severities are the severities of the incident, every field that is set must match and a policy with neither set matches
+// every incident. Only the first policy that matches an incident is used.
+type EscalationPolicy struct {
+	Name       string           `json:"name"`
+	Namespaces []string         `json:"namespaces,omitempty"`
+	Severities []string         `json:"severities,omitempty"`
+	Steps      []EscalationStep `json:"steps"`
+}
+
+// EscalationStep is sent After seconds from the first notification for the incident, through the handler in Notification.
+// Handler is initialized from Notification when the config is loaded.
+type EscalationStep struct {
+	After        int                 `json:"after"`
+	Notification NotificationConfig  `json:"notifications"`
+	Handler      NotificationHandler `json:"-"`
+}
+
+// Delay is the time from the first notification for the incident until the step is sent.
+func (s EscalationStep) Delay() time.Duration {
+	return time.Duration(s.After) * time.Second
+}
+
+// Validate checks that the policy has steps in the order they are sent, and that its patterns and severities are valid.
+func (p EscalationPolicy) Validate() error {
+
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("the escalation policy %v has no steps", p.Name)
+	}
+
+	for i, step := range p.Steps {
+		if step.After <= 0 {
+			return fmt.Errorf("step %v of the escalation policy %v must be sent after more than 0 seconds", i+1, p.Name)
+		}
+		if i > 0 && step.After <= p.Steps[i-1].After {
+			return fmt.Errorf("step %v of the escalation policy %v must be sent after step %v", i+1, p.Name, i)
+		}
+	}
+
+	for _, pattern := range p.Namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern '%v' in the escalation policy %v : %v", pattern, p.Name, err)
+		}
+	}
+
+	for _, severity := range p.Severities {
+		if !validSeverity(severity) {
+			return fmt.Errorf("invalid severity '%v' in the escalation policy %v, it must be '%v', '%v' or '%v'", severity, p.Name,
+				SeverityCritical, SeverityWarning, SeverityInfo)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports if the policy applies to the incident.
+func (p EscalationPolicy) Matches(i Incident) bool {
+
+	if len(p.Namespaces) > 0 {
+		matched := false
+		for _, pattern := range p.Namespaces {
+			if ok, _ := path.Match(pattern, i.Namespace); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(p.Severities) == 0 {
+		return true
+	}
+
+	for _, severity := range p.Severities {
+		if severity == i.Severity {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestEscalationPolicyMatches tests that a policy only matches the incidents in its namespaces with one of its severities.
+func TestEscalationPolicyMatches(t *testing.T) {
+
+	incident := Incident{Namespace: "payments", Workload: "Deployment/api", Severity: SeverityCritical}
+
+	testSuite := map[string]struct {
+		policy      EscalationPolicy
+		expectMatch bool
+	}{
+		"A policy with no namespaces or severities should match every incident": {
+			policy:      EscalationPolicy{Name: "everything"},
+			expectMatch: true,
+		},
+		"The namespaces should be glob patterns": {
+			policy:      EscalationPolicy{Name: "pay", Namespaces: []string{"orders", "pay*"}},
+			expectMatch: true,
+		},
+		"Another namespace should not match": {
+			policy: EscalationPolicy{Name: "orders", Namespaces: []string{"orders"}},
+		},
+		"The severity of the incident should match": {
+			policy:      EscalationPolicy{Name: "pager", Namespaces: []string{"payments"}, Severities: []string{SeverityCritical}},
+			expectMatch: true,
+		},
+		"Another severity should not match": {
+			policy: EscalationPolicy{Name: "warnings", Severities: []string{SeverityWarning, SeverityInfo}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if match := testCase.policy.Matches(incident); match != testCase.expectMatch {
+			t.Errorf("Expected the policy to match : %v, but received %v", testCase.expectMatch, match)
+		}
+	}
+
+}
+
+// TestEscalationPolicyValidate tests that Validate() only accepts policies whose steps are in order and whose patterns and severities
+// are valid.
+func TestEscalationPolicyValidate(t *testing.T) {
+
+	testSuite := map[string]struct {
+		policy      EscalationPolicy
+		expectError bool
+	}{
+		"Steps in order should be valid": {
+			policy: EscalationPolicy{Name: "pager", Severities: []string{SeverityCritical}, Steps: []EscalationStep{{After: 600}, {After: 1800}}},
+		},
+		"A policy without steps should fail": {
+			policy:      EscalationPolicy{Name: "pager"},
+			expectError: true,
+		},
+		"A step without a delay should fail": {
+			policy:      EscalationPolicy{Name: "pager", Steps: []EscalationStep{{}}},
+			expectError: true,
+		},
+		"Steps out of order should fail": {
+			policy:      EscalationPolicy{Name: "pager", Steps: []EscalationStep{{After: 1800}, {After: 600}}},
+			expectError: true,
+		},
+		"An invalid namespace pattern should fail": {
+			policy:      EscalationPolicy{Name: "pager", Namespaces: []string{"pay["}, Steps: []EscalationStep{{After: 600}}},
+			expectError: true,
+		},
+		"An unknown severity should fail": {
+			policy:      EscalationPolicy{Name: "pager", Severities: []string{"page"}, Steps: []EscalationStep{{After: 600}}},
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if err := testCase.policy.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
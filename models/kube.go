@@ -2,140 +2,548 @@ package models
 
 import (
 	"fmt"
-	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 )
 
-// PodStatusInformation is a small struct that stores info about pod and container changes.
+// PodStatusInformation is a small struct that stores info about a pod and the failures of its containers.
 type PodStatusInformation struct {
-	Namespace     string
-	PodName       string
+	Namespace string
+	PodName   string
+	// OwnerKind and OwnerName are the workload that created the pod (e.g. Deployment and api), they are empty for a bare pod.
+	OwnerKind string `json:",omitempty"`
+	OwnerName string `json:",omitempty"`
+	StartedAt time.Time
+	// Reason and Message are the pod level status (e.g. Evicted), the container level details are in Failures.
+	Reason   string
+	Message  string
+	Seen     time.Time
+	Failures []ContainerFailure
+	// Warning event specifics, these are only populated by LoadEvent(). InvolvedObject is in the form Kind/Name.
+	InvolvedObject string `json:",omitempty"`
+	Count          int32  `json:",omitempty"`
+	// ImagePullSecrets are the pull secrets referenced by the pod, they are only set when a container is unable to pull its image.
+	ImagePullSecrets []string `json:",omitempty"`
+	// NodeName is the node the pod was scheduled on, Node is only set if the node could be read.
+	NodeName string           `json:",omitempty"`
+	Node     *NodeInformation `json:",omitempty"`
+	// Labels are the labels of the pod, for a warning event they are only set if the pod could be read.
+	Labels map[string]string `json:",omitempty"`
+	// Channel and Severity are set from the hubbub.io annotations on the pod or its owner, see LoadAnnotations().
+	Channel  string `json:",omitempty"`
+	Severity string `json:",omitempty"`
+	// Repeats is the number of times the failure happened again but was not notified, over the RepeatWindow since the
+	// previous notification.
+	Repeats      int           `json:",omitempty"`
+	RepeatWindow time.Duration `json:",omitempty"`
+}
+
+// Incident is a workload that has failed and not yet recovered. It is opened by the first notification for the workload and
+// resolved once the workload is available again, the resolution is sent with BuildResolvedBody.
+type Incident struct {
+	Namespace string
+	// Workload is in the form Kind/Name, or Pod/Name for a pod that has no owner, see PodStatusInformation.Workload().
+	Workload string
+	// Causes are the reasons (or exit codes) of the failures that were notified while the incident was open.
+	Causes   []string
+	Opened   time.Time
+	Resolved time.Time
+	// Notifications is the number of notifications sent for the workload while the incident was open.
+	Notifications int
+	Channel       string `json:",omitempty"`
+	Severity      string `json:",omitempty"`
+	// Escalated is the number of steps of its escalation policy that have been sent for the incident.
+	Escalated int `json:",omitempty"`
+	// Recovering is when the workload was first seen healthy since it last failed, it is zero while the workload is failing.
+	Recovering time.Time `json:",omitempty"`
+	// Failing is when the workload was last seen failing.
+	Failing time.Time `json:",omitempty"`
+	// Labels are the labels of the pod of the last notification, they are used to match the incident against the silences.
+	Labels map[string]string `json:",omitempty"`
+}
+
+// IncidentKey returns the key the incidents of p are tracked on, the namespace and the workload.
+func (p PodStatusInformation) IncidentKey() string {
+	return p.Namespace + "/" + p.Workload()
+}
+
+// Key returns the key the incident is tracked on, see PodStatusInformation.IncidentKey().
+func (i Incident) Key() string {
+	return i.Namespace + "/" + i.Workload
+}
+
+// Failure returns the incident as the failure that is matched against the silences, the failures are its causes. A cause that
+// is an exit code only has the exit code, so a silence for both a reason and an exit code does not match it.
+func (i Incident) Failure() PodStatusInformation {
+
+	p := PodStatusInformation{Namespace: i.Namespace, Labels: i.Labels}
+
+	kind, name := "", i.Workload
+	if parts := strings.SplitN(i.Workload, "/", 2); len(parts) == 2 {
+		kind, name = parts[0], parts[1]
+	}
+	if kind == "Pod" {
+		p.PodName = name
+	} else {
+		p.OwnerKind, p.OwnerName = kind, name
+	}
+
+	for _, cause := range i.Causes {
+		f := ContainerFailure{Reason: cause}
+		if code, err := strconv.Atoi(strings.TrimPrefix(cause, "exit code ")); err == nil {
+			f = ContainerFailure{ExitCode: code}
+		}
+		p.Failures = append(p.Failures, f)
+	}
+
+	return p
+}
+
+// TimeToRecovery is the time from the first notification for the workload until it recovered.
+func (i Incident) TimeToRecovery() time.Duration {
+	return i.Resolved.Sub(i.Opened)
+}
+
+// The annotations that can be set on a pod or its owner to control the notifications for the pod. Annotations on the pod
+// take precedence over the ones on its owner.
+const (
+	// AnnotationIgnore set to "true" stops any notifications for the pod.
+	AnnotationIgnore = "hubbub.io/ignore"
+	// AnnotationChannel overrides the slack channel the notification is posted to.
+	AnnotationChannel = "hubbub.io/channel"
+	// AnnotationSeverity sets the severity of the notification.
+	AnnotationSeverity = "hubbub.io/severity"
+	// AnnotationIgnoreExitCodes is a comma seperated list of exit codes that do not generate notifications, e.g. "143".
+	AnnotationIgnoreExitCodes = "hubbub.io/ignore-exit-codes"
+)
+
+// NodeInformation stores the topology labels of a node and the status of its conditions ("True", "False" or "Unknown")
+// at the time the failure was seen.
+type NodeInformation struct {
+	Zone           string `json:",omitempty"`
+	Region         string `json:",omitempty"`
+	InstanceType   string `json:",omitempty"`
+	Ready          string
+	MemoryPressure string
+	DiskPressure   string
+}
+
+// The node topology labels, the beta labels are still used by older clusters so they are checked when the GA labels are missing.
+var (
+	nodeZoneLabels         = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+	nodeRegionLabels       = []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}
+	nodeInstanceTypeLabels = []string{"node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"}
+)
+
+// ContainerFailure stores the details of a single failed container in a pod. For a warning event it holds the event itself
+// and ContainerName is only set when the event refers to a single container.
+type ContainerFailure struct {
 	ContainerName string
 	Image         string
-	StartedAt     time.Time
 	FinishedAt    time.Time
 	ExitCode      int
 	Reason        string
 	Message       string
-	Seen          time.Time
+	// RestartCount is the number of times the container has been restarted, LastTerminationReason is the reason
+	// the container last terminated and is only set for containers in CrashLoopBackOff.
+	RestartCount          int32  `json:",omitempty"`
+	LastTerminationReason string `json:",omitempty"`
+	// Registry is only set when the container is waiting on an image it is unable to pull.
+	Registry string `json:",omitempty"`
+	// InitContainerOrder is the position (starting at 1) of the failed container among the InitContainerCount init containers
+	// of the pod, it is 0 when the failed container is an app container.
+	InitContainerOrder int `json:",omitempty"`
+	InitContainerCount int `json:",omitempty"`
+	// Logs are the last lines logged by the container, they are only set when logs are enabled in the config.
+	Logs string `json:",omitempty"`
+}
+
+// Redactor scrubs secrets out of container logs before they are attached to a notification.
+type Redactor func(logs string) string
+
+// CrashLoopBackOff is the waiting reason of a container that keeps crashing and is being restarted with a back-off.
+const CrashLoopBackOff = "CrashLoopBackOff"
+
+// imagePullReasons are the waiting reasons of a container whose image can not be pulled.
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
 }
 
 // Load takes a *v1.Pod and loads the attributes into the PodStatusInformation struct. it has some
 // logic to ensure the we dont alert on pending/succefully completed pods and things of that nature.
+// Every failed container is loaded, so a sidecar failing alongside the app container is reported as well.
 func (p *PodStatusInformation) Load(pod *v1.Pod) {
 
 	p.Namespace = pod.Namespace
 	p.StartedAt = pod.CreationTimestamp.Time
 	p.PodName = pod.Name
+	p.Reason = pod.Status.Reason
 	p.Message = pod.Status.Message
+	p.NodeName = pod.Spec.NodeName
+	p.Labels = pod.Labels
 	p.Seen = time.Now()
 
-	if len(pod.Status.ContainerStatuses) == 0 && pod.Status.Phase == v1.PodFailed { // skip pending in default case
+	noStatuses := len(pod.Status.ContainerStatuses) == 0 && len(pod.Status.InitContainerStatuses) == 0
+	if noStatuses && pod.Status.Phase == v1.PodFailed { // skip pending in default case
 
-		p.FinishedAt = pod.CreationTimestamp.Time
-		p.ExitCode = -1
-		p.Reason = pod.Status.Reason
-		p.Image = "Unknown"
-		p.ContainerName = "Unknown"
+		failure := ContainerFailure{
+			ContainerName: "Unknown",
+			Image:         "Unknown",
+			FinishedAt:    pod.CreationTimestamp.Time,
+			ExitCode:      -1,
+			Reason:        pod.Status.Reason,
+			Message:       pod.Status.Message,
+		}
 
 		if len(pod.Spec.Containers) > 0 {
-			p.Image = pod.Spec.Containers[0].Image
-			p.ContainerName = pod.Spec.Containers[0].Name
+			failure.Image = pod.Spec.Containers[0].Image
+			failure.ContainerName = pod.Spec.Containers[0].Name
 		}
 
-	} else {
+		p.Failures = append(p.Failures, failure)
+		return
+	}
 
-		for _, cst := range pod.Status.ContainerStatuses {
+	// Init containers run in order before the app containers, if one of them fails the app containers never start
+	for i, cst := range pod.Status.InitContainerStatuses {
+		if failure, ok := p.loadContainer(pod, cst); ok {
+			failure.InitContainerOrder = i + 1
+			failure.InitContainerCount = len(pod.Status.InitContainerStatuses)
+			p.Failures = append(p.Failures, failure)
+		}
+	}
 
-			// Skipping the terminated container
-			if cst.State.Terminated == nil {
-				continue
-			}
+	for _, cst := range pod.Status.ContainerStatuses {
+		if failure, ok := p.loadContainer(pod, cst); ok {
+			p.Failures = append(p.Failures, failure)
+		}
+	}
+}
 
-			// If we land in default we need to ensure that we dont alert on good pods
-			if cst.State.Terminated.Reason != "Completed" {
-				p.FinishedAt = cst.State.Terminated.FinishedAt.Time
-				p.Image = cst.Image
-				p.ContainerName = cst.Name
-				p.ExitCode = int(cst.State.Terminated.ExitCode)
-				p.Reason = cst.State.Terminated.Reason
-				p.Message = cst.State.Terminated.Message
-				break
-			}
+// loadContainer returns the failure of a single container, ok is false if the container has not failed.
+func (p *PodStatusInformation) loadContainer(pod *v1.Pod, cst v1.ContainerStatus) (failure ContainerFailure, ok bool) {
+
+	// A container that keeps crashing spends most of its time waiting to be restarted
+	if cst.State.Waiting != nil && cst.State.Waiting.Reason == CrashLoopBackOff {
+		return p.loadCrashLoop(cst), true
+	}
+
+	// A bad image tag leaves the pod pending with the container waiting on the image
+	if cst.State.Waiting != nil && imagePullReasons[cst.State.Waiting.Reason] {
+		return p.loadImagePull(pod, cst), true
+	}
+
+	// Skipping the containers that are not terminated
+	if cst.State.Terminated == nil {
+		return failure, false
+	}
+
+	// If we land in default we need to ensure that we dont alert on good pods
+	if cst.State.Terminated.Reason == "Completed" {
+		return failure, false
+	}
+
+	return ContainerFailure{
+		ContainerName: cst.Name,
+		Image:         cst.Image,
+		FinishedAt:    cst.State.Terminated.FinishedAt.Time,
+		ExitCode:      int(cst.State.Terminated.ExitCode),
+		Reason:        cst.State.Terminated.Reason,
+		Message:       cst.State.Terminated.Message,
+		RestartCount:  cst.RestartCount,
+	}, true
+}
+
+// loadCrashLoop loads a container stuck in CrashLoopBackOff. The container is waiting to be restarted so the exit code and the
+// time it finished are taken from its last termination state, the message is the back-off message from the kubelet.
+func (p *PodStatusInformation) loadCrashLoop(cst v1.ContainerStatus) ContainerFailure {
+
+	failure := ContainerFailure{
+		ContainerName: cst.Name,
+		Image:         cst.Image,
+		FinishedAt:    p.Seen,
+		ExitCode:      -1,
+		Reason:        CrashLoopBackOff,
+		Message:       cst.State.Waiting.Message,
+		RestartCount:  cst.RestartCount,
+	}
+
+	if last := cst.LastTerminationState.Terminated; last != nil {
+		failure.ExitCode = int(last.ExitCode)
+		failure.LastTerminationReason = last.Reason
+		if !last.FinishedAt.IsZero() {
+			failure.FinishedAt = last.FinishedAt.Time
+		}
+	}
+
+	return failure
+}
+
+// Load takes a *v1.Node and loads its topology labels and conditions into the NodeInformation struct.
+// A condition the node does not report is left as "Unknown".
+func (n *NodeInformation) Load(node *v1.Node) {
+
+	n.Zone = nodeLabel(node, nodeZoneLabels)
+	n.Region = nodeLabel(node, nodeRegionLabels)
+	n.InstanceType = nodeLabel(node, nodeInstanceTypeLabels)
+	n.Ready = string(v1.ConditionUnknown)
+	n.MemoryPressure = string(v1.ConditionUnknown)
+	n.DiskPressure = string(v1.ConditionUnknown)
+
+	for _, condition := range node.Status.Conditions {
+		switch condition.Type {
+		case v1.NodeReady:
+			n.Ready = string(condition.Status)
+		case v1.NodeMemoryPressure:
+			n.MemoryPressure = string(condition.Status)
+		case v1.NodeDiskPressure:
+			n.DiskPressure = string(condition.Status)
 		}
 	}
 }
 
-// IsNew compares fields in p with the ones passed in on lastSeen. The purpose is to validate
-// that a instance of the struct is new and not a repeat or close enough to be considered a repeat.
-//
-// This is done to cut down on redundant messages generated in Watch(). For e.g. a pod that terminates
-// shortly after startup due to an error in program.cs will be restarted
-// by kubernetes where it will proceed to fail again and constantly generate alerts as it goes up and down.
-//
-// Is new returns true if the pod has not been seen for 'x' minutes or it does not match any of the criteria
-func (p PodStatusInformation) IsNew(lastSeen PodStatusInformation, timeSince int) bool {
+// nodeLabel returns the value of the first label in keys that is set on the node.
+func nodeLabel(node *v1.Node, keys []string) string {
+
+	for _, key := range keys {
+		if value, ok := node.Labels[key]; ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Ignored reports if the annotations opt the pod out of notifications.
+func Ignored(annotations map[string]string) bool {
+
+	ignore, _ := strconv.ParseBool(annotations[AnnotationIgnore])
+	return ignore
+}
+
+// MergeAnnotations returns the hubbub.io annotations of the owner overridden by the ones on the pod.
+func MergeAnnotations(owner, pod map[string]string) map[string]string {
 
-	// assume not a failure
-	if p.Image == "" || p.ContainerName == "" || p.FinishedAt.IsZero() {
-		return false
+	merged := map[string]string{}
+	for _, annotations := range []map[string]string{owner, pod} {
+		for k, v := range annotations {
+			if strings.HasPrefix(k, "hubbub.io/") {
+				merged[k] = v
+			}
+		}
 	}
 
-	// Check to see if its been over 'x' minutes, if so its new yet again.
-	if ok := p.timeCheck(lastSeen, timeSince); ok {
-		return true
+	return merged
+}
+
+// LoadAnnotations loads the channel and severity from the annotations into 'p' and drops the failures with an exit code
+// listed in the ignore-exit-codes annotation.
+func (p *PodStatusInformation) LoadAnnotations(annotations map[string]string) {
+
+	p.Channel = annotations[AnnotationChannel]
+	p.Severity = strings.ToLower(annotations[AnnotationSeverity])
+
+	ignored := map[int]bool{}
+	for _, code := range strings.Split(annotations[AnnotationIgnoreExitCodes], ",") {
+		if i, err := strconv.Atoi(strings.TrimSpace(code)); err == nil {
+			ignored[i] = true
+		}
 	}
 
-	// Identical
-	if reflect.DeepEqual(p, lastSeen) {
+	if len(ignored) == 0 {
+		return
+	}
 
-		return false
+	failures := []ContainerFailure{}
+	for _, f := range p.Failures {
+		if !ignored[f.ExitCode] {
+			failures = append(failures, f)
+		}
 	}
+	p.Failures = failures
+}
 
-	if p.PodName == lastSeen.PodName && p.ContainerName == lastSeen.PodName {
+// LoadEvent takes a *v1.Event (a Warning event such as FailedMount or BackOff) and loads the attributes into the PodStatusInformation struct.
+// The event is loaded as the only failure, its container is taken from the field path of the involved object when the event refers to a single container.
+func (p *PodStatusInformation) LoadEvent(e *v1.Event) {
 
-		return false
+	p.Namespace = e.InvolvedObject.Namespace
+	if p.Namespace == "" {
+		p.Namespace = e.Namespace
 	}
 
-	// Same pod, same start time
-	if p.PodName == lastSeen.PodName && p.StartedAt == lastSeen.StartedAt {
+	p.PodName = e.InvolvedObject.Name
+	p.InvolvedObject = e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name
+	p.Seen = time.Now()
 
-		return false
+	p.Count = e.Count
+	if p.Count == 0 && e.Series != nil {
+		p.Count = e.Series.Count
+	}
+	if p.Count == 0 {
+		p.Count = 1
 	}
 
-	// same container, same exit code
-	if p.ContainerName == lastSeen.ContainerName && p.ExitCode == lastSeen.ExitCode {
+	failure := ContainerFailure{
+		ContainerName: fieldPathContainer(e.InvolvedObject.FieldPath),
+		FinishedAt:    EventTime(e),
+		Reason:        e.Reason,
+		Message:       e.Message,
+	}
+	p.Failures = []ContainerFailure{failure}
 
-		return false
+	p.StartedAt = e.FirstTimestamp.Time
+	if p.StartedAt.IsZero() {
+		p.StartedAt = failure.FinishedAt
 	}
+}
+
+// EventTime returns the last time an event was seen. Events created through the events.k8s.io API only set the EventTime
+// so that is used when the LastTimestamp is missing, with the CreationTimestamp as a last resort.
+func EventTime(e *v1.Event) time.Time {
 
-	// same container, same exit code
-	if p.PodName == lastSeen.PodName && p.ExitCode == lastSeen.ExitCode {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
 
-		return false
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
 	}
 
-	return true
+	return e.CreationTimestamp.Time
 }
 
-// timeCheck checks to see if a pod was seen more than 'x' minutes ago. This is acheived
-// by diffing the 'seen' values in both struct ('p' and 'lastSeen') and then seeing if it
-// exceeds the timeSince value provided in the config.
-func (p PodStatusInformation) timeCheck(lastSeen PodStatusInformation, timeSince int) bool {
+// fieldPathContainer returns the container name from an involved object field path, e.g. spec.containers{api} returns api.
+// An empty string is returned if the field path does not refer to a container.
+func fieldPathContainer(fieldPath string) string {
 
-	newPod := p.Seen
-	LastPod := lastSeen.Seen
-	diff := newPod.Sub(LastPod)
+	for _, prefix := range []string{"spec.containers{", "spec.initContainers{"} {
+		if strings.HasPrefix(fieldPath, prefix) && strings.HasSuffix(fieldPath, "}") {
+			return strings.TrimSuffix(strings.TrimPrefix(fieldPath, prefix), "}")
+		}
+	}
 
-	if diff > (time.Minute * time.Duration(timeSince)) {
-		return true
+	return ""
+}
+
+// Failed reports if p holds a failure, a terminated container or a warning event.
+func (p PodStatusInformation) Failed() bool {
+
+	for _, f := range p.Failures {
+		if p.InvolvedObject != "" {
+			if f.Reason != "" {
+				return true
+			}
+		} else if f.Image != "" && f.ContainerName != "" && !f.FinishedAt.IsZero() {
+			return true
+		}
 	}
 
 	return false
+}
+
+// loadImagePull loads a container that is unable to pull its image. The message is the one returned by the kubelet (e.g. manifest unknown)
+// and the image pull secrets referenced by the pod are included as a missing or wrong secret is a common cause. The container never ran, so
+// the time it was seen is used as the time it finished.
+func (p *PodStatusInformation) loadImagePull(pod *v1.Pod, cst v1.ContainerStatus) ContainerFailure {
+
+	if p.ImagePullSecrets == nil {
+		for _, secret := range pod.Spec.ImagePullSecrets {
+			p.ImagePullSecrets = append(p.ImagePullSecrets, secret.Name)
+		}
+	}
+
+	return ContainerFailure{
+		ContainerName: cst.Name,
+		Image:         cst.Image,
+		FinishedAt:    p.Seen,
+		ExitCode:      -1,
+		Reason:        cst.State.Waiting.Reason,
+		Message:       cst.State.Waiting.Message,
+		RestartCount:  cst.RestartCount,
+		Registry:      imageRegistry(cst.Image),
+	}
+}
 
+// IsImagePull returns true if 'f' is a container that is unable to pull its image.
+func (f ContainerFailure) IsImagePull() bool {
+	return imagePullReasons[f.Reason]
+}
+
+// imageRegistry returns the registry of an image reference. The first part of the reference is only a registry if it
+// looks like a host (contains a '.' or ':' or is localhost), otherwise the image is on docker hub.
+func imageRegistry(image string) string {
+
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return "docker.io"
+	}
+
+	if host := image[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+
+	return "docker.io"
+}
+
+// DedupKey returns the key a failure in p is deduplicated on, the namespace, the workload that owns the pod (or the pod itself
+// when it has no owner), the container and the reason. Failures of different replicas of the same workload share a key.
+func (p PodStatusInformation) DedupKey(f ContainerFailure) string {
+	return p.Namespace + "/" + p.Workload() + "/" + f.ContainerName + "/" + f.Cause()
+}
+
+// Workload returns the workload that owns the pod in the form Kind/Name, or Pod/Name when the pod has no owner.
+func (p PodStatusInformation) Workload() string {
+
+	if p.OwnerKind == "" {
+		return "Pod/" + p.PodName
+	}
+
+	return p.OwnerKind + "/" + p.OwnerName
+}
+
+// Cause returns the reason of the failure, or its exit code (e.g. "exit code 1") when it has no reason.
+func (f ContainerFailure) Cause() string {
+
+	if f.Reason == "" {
+		return "exit code " + strconv.Itoa(f.ExitCode)
+	}
+
+	return f.Reason
+}
+
+// Occurrence identifies a single occurrence of a failure in p, a pod that is modified without failing again has the same
+// occurrence. A container is identified by its restarts and the time it finished and an event by its count. A container that
+// is unable to pull its image never finishes, so every pull failure of the pod is the same occurrence.
+func (p PodStatusInformation) Occurrence(f ContainerFailure) string {
+
+	if p.InvolvedObject != "" {
+		return p.InvolvedObject + "/" + strconv.Itoa(int(p.Count))
+	}
+
+	if f.IsImagePull() {
+		return p.PodName
+	}
+
+	return p.PodName + "/" + strconv.Itoa(int(f.RestartCount)) + "/" + strconv.FormatInt(f.FinishedAt.Unix(), 10)
+}
+
+// GroupKey returns the key p is grouped on, the namespace, the workload that owns the pod (or the pod itself when it has no
+// owner) and the reason and exit code of each failure. The replicas of a workload that fail the same way share a key.
+func (p PodStatusInformation) GroupKey() string {
+
+	failures := []string{}
+	for _, f := range p.Failures {
+		failures = append(failures, f.Reason+"/"+strconv.Itoa(f.ExitCode))
+	}
+
+	return p.Namespace + "/" + p.Workload() + "/" + strings.Join(failures, ",")
 }
 
 // ConvertTime converts all of the times found in p to local (EST). This is in place because some
@@ -143,14 +551,21 @@ func (p PodStatusInformation) timeCheck(lastSeen PodStatusInformation, timeSince
 // the end users.
 func (p *PodStatusInformation) ConvertTime(tlocal *time.Location) {
 
-	p.FinishedAt = p.FinishedAt.In(tlocal)
 	p.StartedAt = p.StartedAt.In(tlocal)
 
+	// copied so that converting a copy of 'p' does not change the failures of the original
+	failures := make([]ContainerFailure, len(p.Failures))
+	for i, f := range p.Failures {
+		f.FinishedAt = f.FinishedAt.In(tlocal)
+		failures[i] = f
+	}
+	p.Failures = failures
+
 }
 
-// ExitCodeLookup tries to associate the int exit code in 'p' to a string that describes the exit code.
+// ExitCodeLookup tries to associate the int exit code in 'f' to a string that describes the exit code.
 // E.G. 139 = Segmentation fault
-func (p PodStatusInformation) ExitCodeLookup() string {
+func (f ContainerFailure) ExitCodeLookup() string {
 
 	exitCodes := map[int]string{
 		139: "Segmentation fault.",
@@ -163,7 +578,7 @@ func (p PodStatusInformation) ExitCodeLookup() string {
 		1:   "Application Error.",
 	}
 
-	if i, ok := exitCodes[p.ExitCode]; ok {
+	if i, ok := exitCodes[f.ExitCode]; ok {
 		return i
 	}
 
@@ -171,16 +586,16 @@ func (p PodStatusInformation) ExitCodeLookup() string {
 
 }
 
-// podErrorReason returns a string composed of p.Reason and/or p.Message depending upon
+// podErrorReason returns a string composed of f.Reason and/or f.Message depending upon
 // their values. If both are nil a user friendly nil is returned.
-func podErrorReason(p PodStatusInformation) string {
-
-	if p.Reason != "" && p.Message != "" {
-		return fmt.Sprintf("Failure reason received : `%v - %v`", p.Reason, p.Message)
-	} else if p.Message != "" {
-		return fmt.Sprintf("Failure reason received : `%v`", p.Message)
-	} else if p.Reason != "" {
-		return fmt.Sprintf("Failure reason received : `%v`", p.Reason)
+func podErrorReason(f ContainerFailure) string {
+
+	if f.Reason != "" && f.Message != "" {
+		return fmt.Sprintf("Failure reason received : `%v - %v`", f.Reason, f.Message)
+	} else if f.Message != "" {
+		return fmt.Sprintf("Failure reason received : `%v`", f.Message)
+	} else if f.Reason != "" {
+		return fmt.Sprintf("Failure reason received : `%v`", f.Reason)
 	} else {
 		return "Unable to determine the reason for the failure."
 	}
@@ -188,10 +603,10 @@ func podErrorReason(p PodStatusInformation) string {
 }
 
 // podErroCode returns a concat of the errorcode (int) and that error codes meaning if one is returned via ExitCodeLookup()
-func podErrorCode(p PodStatusInformation) string {
+func podErrorCode(f ContainerFailure) string {
 
-	errorDetails := strconv.Itoa(p.ExitCode)
-	errInfo := p.ExitCodeLookup()
+	errorDetails := strconv.Itoa(f.ExitCode)
+	errInfo := f.ExitCodeLookup()
 
 	if errInfo != "" {
 		return fmt.Sprintf("Error code : %v `%v`", errorDetails, errInfo)
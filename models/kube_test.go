@@ -1,73 +1,33 @@
 package models
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // TestPod is a package wide PodStatusInformation used in all of the model tests as a base.
 var TestPod = PodStatusInformation{
-	Namespace:     "hubbub",
-	PodName:       "hubbub",
-	ContainerName: "hubbub",
-	Image:         "hubbub",
-	StartedAt:     time.Now(),
-	FinishedAt:    time.Now(),
-	ExitCode:      2,
-	Reason:        "hubbub",
-	Message:       "hubbub",
-	Seen:          time.Now(),
-}
-
-// TestTimeCheck tests the timeCheck() method on the PodStatusInformation struct.
-// This is also tested in the TestIsNew() function
-func TestTimeCheck(t *testing.T) {
-
-	testSuite := map[string]struct {
-		expectedReturn bool
-		timeDiff       time.Duration
-		timeBack       int
-	}{
-		"timeCheck should return true #1": {
-			expectedReturn: true,
-			timeDiff:       time.Minute * -7,
-			timeBack:       2,
-		},
-		"timeCheck should return true #2": {
-			expectedReturn: true,
-			timeDiff:       time.Hour * -1,
-			timeBack:       15,
-		},
-		"timeCheck should return false #1": {
-			expectedReturn: false,
-			timeDiff:       time.Hour * 1,
-			timeBack:       30,
-		},
-		"timeCheck should return false #2": {
-			expectedReturn: false,
-			timeDiff:       time.Minute * 50,
-			timeBack:       25,
-		},
-	}
-
-	for testName, testCase := range testSuite {
-
-		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
-		fakePod := TestPod
-		fakePod.Seen = time.Now().Add(testCase.timeDiff)
-		ok := TestPod.timeCheck(fakePod, testCase.timeBack)
-
-		if ok != testCase.expectedReturn {
-			t.Errorf("expected %v but received %v", testCase.expectedReturn, ok)
-		} else {
-			t.Logf("received the expected response from timeCheck()")
-		}
-	}
-
+	Namespace: "hubbub",
+	PodName:   "hubbub",
+	StartedAt: time.Now(),
+	Seen:      time.Now(),
+	Failures: []ContainerFailure{{
+		ContainerName: "hubbub",
+		Image:         "hubbub",
+		FinishedAt:    time.Now(),
+		ExitCode:      2,
+		Reason:        "hubbub",
+		Message:       "hubbub",
+	}},
 }
 
-// TestExitCode tests the ExitCodeLookup() on the PodStatusInformation struct
+// TestExitCode tests the ExitCodeLookup() on the ContainerFailure struct
 func TestExitCode(t *testing.T) {
 
 	testSuite := map[string]struct {
@@ -104,9 +64,9 @@ func TestExitCode(t *testing.T) {
 	for testName, testCase := range testSuite {
 
 		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
-		fakePod := TestPod
-		fakePod.ExitCode = testCase.exitCode
-		response := fakePod.ExitCodeLookup()
+		failure := TestPod.Failures[0]
+		failure.ExitCode = testCase.exitCode
+		response := failure.ExitCodeLookup()
 
 		if response != testCase.expectedReturn {
 			t.Errorf("expected %v but received %v", testCase.expectedReturn, response)
@@ -150,7 +110,9 @@ func TestConvertTime(t *testing.T) {
 
 		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
 
+		// pinned to January so that America/New_York is not on daylight saving time
 		fakePod := TestPod
+		fakePod.StartedAt = time.Date(2020, time.January, 15, 12, 0, 0, 0, time.UTC).Add(testCase.timeOffset)
 		timeLocation, _ := time.LoadLocation(testCase.timeZone)
 
 		fakePod.ConvertTime(timeLocation)
@@ -165,67 +127,36 @@ func TestConvertTime(t *testing.T) {
 
 }
 
-// TestIsNew tests the IsNew() method, which determines if a PodStatusInformation struct is new or not.
-func TestIsNew(t *testing.T) {
+// TestLoadEvent tests the LoadEvent() method which loads a Warning event into a PodStatusInformation struct.
+func TestLoadEvent(t *testing.T) {
+
+	now := meta_v1.Now()
 
 	testSuite := map[string]struct {
-		image            string
-		continerName     string
-		podName          string
-		seen             time.Time
-		finishedAt       time.Time
-		startedAt        time.Time
-		timeDif          time.Time
-		timeBack         int
-		exitCode         int
-		expectedResponse bool
+		event             v1.Event
+		expectedContainer string
+		expectedCount     int32
 	}{
-		"IsNew should return true": {
-			image:            "hubbub",
-			continerName:     "hubbub",
-			podName:          "hubbub",
-			expectedResponse: true,
-			timeBack:         2,
-		},
-		"IsNew should return true due to the time difference": {
-			image:            TestPod.Image,
-			continerName:     TestPod.ContainerName,
-			podName:          TestPod.PodName,
-			seen:             time.Now().Add(time.Minute * -9),
-			expectedResponse: true,
-			timeBack:         2,
-		},
-		"IsNew should return false as pod and container names match": {
-			image:            TestPod.Image,
-			continerName:     TestPod.ContainerName,
-			podName:          TestPod.PodName,
-			finishedAt:       TestPod.FinishedAt,
-			startedAt:        TestPod.StartedAt,
-			seen:             TestPod.Seen,
-			expectedResponse: false,
-			timeBack:         20,
-		},
-		"IsNew should return false as the structs are identical": {
-			expectedResponse: false,
-			timeBack:         12,
-		},
-		"IsNew should return false because of nil values": {
-			image:            "",
-			continerName:     "",
-			podName:          "",
-			expectedResponse: false,
-			timeBack:         12,
-		},
-		"IsNew should return false container name and exit code match": {
-			image:            "hubbub",
-			continerName:     TestPod.ContainerName,
-			podName:          "hubbub",
-			exitCode:         TestPod.ExitCode,
-			finishedAt:       TestPod.FinishedAt,
-			startedAt:        TestPod.StartedAt,
-			seen:             TestPod.Seen,
-			expectedResponse: false,
-			timeBack:         21,
+		"An event about a container should set the container name": {
+			event: v1.Event{
+				InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "api-1", Namespace: "hubbub", FieldPath: "spec.containers{api}"},
+				Reason:         "BackOff",
+				Message:        "Back-off restarting failed container",
+				Count:          4,
+				FirstTimestamp: now,
+				LastTimestamp:  now,
+			},
+			expectedContainer: "api",
+			expectedCount:     4,
+		},
+		"An event about the pod should not set a container": {
+			event: v1.Event{
+				InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "api-1", Namespace: "hubbub"},
+				Reason:         "FailedScheduling",
+				Message:        "0/3 nodes are available",
+				EventTime:      meta_v1.NewMicroTime(now.Time),
+			},
+			expectedCount: 1,
 		},
 	}
 
@@ -233,31 +164,469 @@ func TestIsNew(t *testing.T) {
 
 		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
 
-		fakePod := TestPod
-		timeZone := "America/New_York"
-		timeLocation, _ := time.LoadLocation(timeZone)
-		fakePod.ConvertTime(timeLocation)
-		
-		// everything will fall into this clause aside from the one testing deepequal
-		// "IsNew should return false as the structs are identical"
-		// and the the failure for nil values 
-		// "IsNew should return false because of nil values"
-		if testCase.podName != "" {
-			fakePod.Image = testCase.image
-			fakePod.ContainerName = testCase.continerName
-			fakePod.PodName = testCase.podName
-			fakePod.Seen = testCase.seen
-			fakePod.StartedAt = testCase.startedAt
-			fakePod.FinishedAt = testCase.finishedAt
+		p := PodStatusInformation{}
+		p.LoadEvent(&testCase.event)
+
+		if p.InvolvedObject != "Pod/api-1" || p.PodName != "api-1" || p.Namespace != "hubbub" {
+			t.Errorf("Expected the involved object Pod/api-1 in hubbub but received %v (%v) in %v", p.InvolvedObject, p.PodName, p.Namespace)
 		}
 
-		ok := TestPod.IsNew(fakePod, testCase.timeBack)
-		if ok != testCase.expectedResponse {
-			t.Errorf("expected %v but received %v", testCase.expectedResponse, ok)
-		} else {
-			t.Logf("received the expected response from IsNew()")
+		if len(p.Failures) != 1 {
+			t.Fatalf("Expected the event to be loaded as a single failure but received %v", len(p.Failures))
+		}
+		f := p.Failures[0]
+
+		if f.ContainerName != testCase.expectedContainer {
+			t.Errorf("Expected the container %v but received %v", testCase.expectedContainer, f.ContainerName)
+		}
+
+		if p.Count != testCase.expectedCount {
+			t.Errorf("Expected the count %v but received %v", testCase.expectedCount, p.Count)
+		}
+
+		if f.Reason != testCase.event.Reason || f.Message != testCase.event.Message {
+			t.Errorf("Expected the reason and message to be copied from the event but received %v - %v", f.Reason, f.Message)
+		}
+
+		if f.FinishedAt.IsZero() || p.StartedAt.IsZero() {
+			t.Errorf("Expected the event times to be set")
+		}
+
+		if !p.Failed() {
+			t.Errorf("Expected the event to be a failure")
+		}
+	}
+
+}
+
+// TestLoad tests the Load() method which loads the failed containers of a pod into a PodStatusInformation struct.
+// The expected values are checked against the first failure.
+func TestLoad(t *testing.T) {
+
+	finished := meta_v1.NewTime(time.Now().Add(time.Minute * -2))
+
+	testSuite := map[string]struct {
+		statuses         []v1.ContainerStatus
+		expectedFailures int
+		expectedReason   string
+		expectedExitCode int
+		expectedRestarts int32
+		expectedMessage  string
+		secrets          []string
+		// init containers
+		initStatuses      []v1.ContainerStatus
+		expectedInitOrder int
+	}{
+		"A terminated container should be loaded": {
+			statuses: []v1.ContainerStatus{{
+				Name:         "api",
+				Image:        "hubbub:1",
+				RestartCount: 1,
+				State:        v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled", FinishedAt: finished}},
+			}},
+			expectedFailures: 1,
+			expectedReason:   "OOMKilled",
+			expectedExitCode: 137,
+			expectedRestarts: 1,
+		},
+		"A completed container should be skipped": {
+			statuses: []v1.ContainerStatus{{
+				Name:  "api",
+				Image: "hubbub:1",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed", FinishedAt: finished}},
+			}},
+		},
+		"A container in CrashLoopBackOff should be loaded from its last termination state": {
+			statuses: []v1.ContainerStatus{{
+				Name:                 "api",
+				Image:                "hubbub:1",
+				RestartCount:         6,
+				State:                v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: CrashLoopBackOff, Message: "back-off 2m40s restarting failed container=api"}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", FinishedAt: finished}},
+			}},
+			expectedFailures: 1,
+			expectedReason:   CrashLoopBackOff,
+			expectedExitCode: 1,
+			expectedRestarts: 6,
+			expectedMessage:  "back-off 2m40s restarting failed container=api",
+		},
+		"A container that is unable to pull its image should be loaded": {
+			statuses: []v1.ContainerStatus{{
+				Name:  "api",
+				Image: "myregistry.azurecr.io/hubbub:nope",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "manifest unknown"}},
+			}},
+			secrets:          []string{"acr-pull"},
+			expectedFailures: 1,
+			expectedReason:   "ErrImagePull",
+			expectedExitCode: -1,
+			expectedMessage:  "manifest unknown",
+		},
+		"A failed init container should be loaded with its order": {
+			initStatuses: []v1.ContainerStatus{
+				{
+					Name:  "wait-for-db",
+					Image: "busybox",
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed", FinishedAt: finished}},
+				},
+				{
+					Name:  "migrate",
+					Image: "hubbub-migrations:1",
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 2, Reason: "Error", FinishedAt: finished}},
+				},
+			},
+			statuses: []v1.ContainerStatus{{
+				Name:  "api",
+				Image: "hubbub:1",
+				State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "PodInitializing"}},
+			}},
+			expectedFailures:  1,
+			expectedReason:    "Error",
+			expectedExitCode:  2,
+			expectedInitOrder: 2,
+		},
+		"Completed init containers should be skipped": {
+			initStatuses: []v1.ContainerStatus{{
+				Name:  "migrate",
+				Image: "hubbub-migrations:1",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed", FinishedAt: finished}},
+			}},
+			statuses: []v1.ContainerStatus{{
+				Name:  "api",
+				Image: "hubbub:1",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+		"Every failed container should be loaded": {
+			statuses: []v1.ContainerStatus{
+				{
+					Name:  "api",
+					Image: "hubbub:1",
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled", FinishedAt: finished}},
+				},
+				{
+					Name:  "envoy",
+					Image: "envoyproxy/envoy:v1.12",
+					State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", FinishedAt: finished}},
+				},
+				{
+					Name:  "logger",
+					Image: "fluent-bit:1.3",
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				},
+			},
+			expectedFailures: 2,
+			expectedReason:   "OOMKilled",
+			expectedExitCode: 137,
+		},
+		"A running container should be skipped": {
+			statuses: []v1.ContainerStatus{{
+				Name:  "api",
+				Image: "hubbub:1",
+				State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		pod := &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "api-1", Namespace: "hubbub"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: testCase.statuses, InitContainerStatuses: testCase.initStatuses},
+		}
+		for _, secret := range testCase.secrets {
+			pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: secret})
+		}
+
+		p := PodStatusInformation{}
+		p.Load(pod)
+
+		if len(p.Failures) != testCase.expectedFailures {
+			t.Errorf("Expected %v failures but received %v", testCase.expectedFailures, len(p.Failures))
+		}
+
+		if p.Failed() != (testCase.expectedFailures > 0) {
+			t.Errorf("Expected a failure : %v, but received %v", testCase.expectedFailures > 0, p.Failed())
+		}
+
+		if len(p.Failures) == 0 || testCase.expectedFailures == 0 {
+			continue
+		}
+		f := p.Failures[0]
+
+		if f.Reason != testCase.expectedReason || f.ExitCode != testCase.expectedExitCode || f.RestartCount != testCase.expectedRestarts {
+			t.Errorf("Expected %v, %v, %v but received %v, %v, %v", testCase.expectedReason, testCase.expectedExitCode, testCase.expectedRestarts, f.Reason, f.ExitCode, f.RestartCount)
+		}
+
+		if testCase.expectedMessage != "" && f.Message != testCase.expectedMessage {
+			t.Errorf("Expected the message %v but received %v", testCase.expectedMessage, f.Message)
+		}
+
+		if f.InitContainerOrder != testCase.expectedInitOrder {
+			t.Errorf("Expected the init container order %v but received %v", testCase.expectedInitOrder, f.InitContainerOrder)
+		}
+
+		if testCase.expectedInitOrder > 0 && (f.InitContainerCount != len(testCase.initStatuses) || f.Image != testCase.initStatuses[f.InitContainerOrder-1].Image) {
+			t.Errorf("Expected the init container count and image to be loaded but received %v and %v", f.InitContainerCount, f.Image)
+		}
+
+		if !reflect.DeepEqual(p.ImagePullSecrets, testCase.secrets) {
+			t.Errorf("Expected the image pull secrets %v but received %v", testCase.secrets, p.ImagePullSecrets)
+		}
+
+		// a container that can not pull its image never ran
+		if f.IsImagePull() {
+			continue
+		}
+
+		if !f.FinishedAt.Equal(finished.Time) {
+			t.Errorf("Expected the container to have finished at %v but received %v", finished.Time, f.FinishedAt)
+		}
+	}
+
+}
+
+
+// TestImageRegistry tests imageRegistry() which pulls the registry out of an image reference.
+func TestImageRegistry(t *testing.T) {
+
+	testSuite := map[string]string{
+		"nginx":                             "docker.io",
+		"nginx:1.17":                        "docker.io",
+		"library/nginx:1.17":                "docker.io",
+		"myregistry.azurecr.io/team/api:v2": "myregistry.azurecr.io",
+		"localhost/api:dev":                 "localhost",
+		"registry:5000/api@sha256:0123456789abcde": "registry:5000",
+		"gcr.io/google-containers/pause:3.1":       "gcr.io",
+	}
+
+	for image, expected := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", image)
+
+		if registry := imageRegistry(image); registry != expected {
+			t.Errorf("expected %v but received %v", expected, registry)
+		}
+	}
+
+}
+
+// TestLoadNode tests the Load() method on NodeInformation which loads the topology labels and conditions of a node.
+func TestLoadNode(t *testing.T) {
+
+	testSuite := map[string]struct {
+		labels     map[string]string
+		conditions []v1.NodeCondition
+		expected   NodeInformation
+	}{
+		"The GA topology labels and the conditions should be loaded": {
+			labels: map[string]string{
+				"topology.kubernetes.io/zone":      "eastus-1",
+				"topology.kubernetes.io/region":    "eastus",
+				"node.kubernetes.io/instance-type": "Standard_D4s_v3",
+			},
+			conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: v1.ConditionTrue},
+				{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue},
+				{Type: v1.NodeDiskPressure, Status: v1.ConditionFalse},
+				{Type: v1.NodePIDPressure, Status: v1.ConditionFalse},
+			},
+			expected: NodeInformation{Zone: "eastus-1", Region: "eastus", InstanceType: "Standard_D4s_v3", Ready: "True", MemoryPressure: "True", DiskPressure: "False"},
+		},
+		"The beta topology labels should be used when the GA labels are missing": {
+			labels: map[string]string{
+				"failure-domain.beta.kubernetes.io/zone":   "us-east-1a",
+				"failure-domain.beta.kubernetes.io/region": "us-east-1",
+				"beta.kubernetes.io/instance-type":         "m5.large",
+			},
+			conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}},
+			expected:   NodeInformation{Zone: "us-east-1a", Region: "us-east-1", InstanceType: "m5.large", Ready: "False", MemoryPressure: "Unknown", DiskPressure: "Unknown"},
+		},
+		"A node without labels or conditions should be unknown": {
+			expected: NodeInformation{Ready: "Unknown", MemoryPressure: "Unknown", DiskPressure: "Unknown"},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		node := &v1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-1", Labels: testCase.labels}, Status: v1.NodeStatus{Conditions: testCase.conditions}}
+		n := NodeInformation{}
+		n.Load(node)
+
+		if n != testCase.expected {
+			t.Errorf("expected %+v but received %+v", testCase.expected, n)
+		}
+	}
+
+}
+
+// TestLoadAnnotations tests MergeAnnotations(), Ignored() and the LoadAnnotations() method which apply the hubbub.io annotations of a pod and its owner.
+func TestLoadAnnotations(t *testing.T) {
+
+	testSuite := map[string]struct {
+		owner            map[string]string
+		pod              map[string]string
+		expectedIgnored  bool
+		expectedChannel  string
+		expectedSeverity string
+		expectedFailures int
+	}{
+		"No annotations should leave the pod unchanged": {
+			expectedFailures: 2,
+		},
+		"The channel and severity of the owner should be loaded": {
+			owner:            map[string]string{AnnotationChannel: "#payments", AnnotationSeverity: "Critical"},
+			expectedChannel:  "#payments",
+			expectedSeverity: "critical",
+			expectedFailures: 2,
+		},
+		"The pod annotations should override the owner": {
+			owner:            map[string]string{AnnotationChannel: "#payments", AnnotationIgnore: "true"},
+			pod:              map[string]string{AnnotationChannel: "#payments-canary", AnnotationIgnore: "false"},
+			expectedChannel:  "#payments-canary",
+			expectedFailures: 2,
+		},
+		"An ignore annotation should ignore the pod": {
+			pod:              map[string]string{AnnotationIgnore: "true"},
+			expectedIgnored:  true,
+			expectedFailures: 2,
+		},
+		"Failures with an ignored exit code should be dropped": {
+			pod:              map[string]string{AnnotationIgnoreExitCodes: "143, nope"},
+			expectedFailures: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		annotations := MergeAnnotations(testCase.owner, testCase.pod)
+		if Ignored(annotations) != testCase.expectedIgnored {
+			t.Errorf("Expected ignored to be %v", testCase.expectedIgnored)
 		}
 
+		p := PodStatusInformation{Failures: []ContainerFailure{{ContainerName: "api", ExitCode: 1}, {ContainerName: "envoy", ExitCode: 143}}}
+		p.LoadAnnotations(annotations)
+
+		if p.Channel != testCase.expectedChannel || p.Severity != testCase.expectedSeverity {
+			t.Errorf("Expected the channel %v and severity %v but received %v and %v", testCase.expectedChannel, testCase.expectedSeverity, p.Channel, p.Severity)
+		}
+
+		if len(p.Failures) != testCase.expectedFailures {
+			t.Errorf("Expected %v failures but received %v", testCase.expectedFailures, len(p.Failures))
+		}
+	}
+
+}
+
+// TestDedupKey tests the DedupKey() and Occurrence() methods that the notifications are deduplicated on and the GroupKey()
+// method they are grouped on.
+func TestDedupKey(t *testing.T) {
+
+	finished := time.Now()
+
+	testSuite := map[string]struct {
+		pod                PodStatusInformation
+		expectedKey        string
+		expectedOccurrence string
+		expectedGroupKey   string
+	}{
+		"The key of a pod with an owner should use the owner": {
+			pod: PodStatusInformation{
+				Namespace: "payments",
+				PodName:   "api-7d9f-1",
+				OwnerKind: "Deployment",
+				OwnerName: "api",
+				Failures:  []ContainerFailure{{ContainerName: "api", Reason: "OOMKilled", RestartCount: 3, FinishedAt: finished}},
+			},
+			expectedKey:        "payments/Deployment/api/api/OOMKilled",
+			expectedOccurrence: "api-7d9f-1/3/" + strconv.FormatInt(finished.Unix(), 10),
+			expectedGroupKey:   "payments/Deployment/api/OOMKilled/0",
+		},
+		"The key of a bare pod without a reason should use the pod and exit code": {
+			pod: PodStatusInformation{
+				Namespace: "payments",
+				PodName:   "debug",
+				Failures:  []ContainerFailure{{ContainerName: "shell", ExitCode: 2, FinishedAt: finished}},
+			},
+			expectedKey:        "payments/Pod/debug/shell/exit code 2",
+			expectedOccurrence: "debug/0/" + strconv.FormatInt(finished.Unix(), 10),
+			expectedGroupKey:   "payments/Pod/debug//2",
+		},
+		"Every image pull failure of a pod should be the same occurrence": {
+			pod: PodStatusInformation{
+				Namespace: "payments",
+				PodName:   "api-7d9f-1",
+				Failures:  []ContainerFailure{{ContainerName: "api", Reason: "ImagePullBackOff", FinishedAt: finished}},
+			},
+			expectedKey:        "payments/Pod/api-7d9f-1/api/ImagePullBackOff",
+			expectedOccurrence: "api-7d9f-1",
+			expectedGroupKey:   "payments/Pod/api-7d9f-1/ImagePullBackOff/0",
+		},
+		"An event occurrence should use its count": {
+			pod: PodStatusInformation{
+				Namespace:      "payments",
+				PodName:        "api-7d9f-1",
+				InvolvedObject: "Pod/api-7d9f-1",
+				Count:          4,
+				Failures:       []ContainerFailure{{Reason: "FailedMount"}},
+			},
+			expectedKey:        "payments/Pod/api-7d9f-1//FailedMount",
+			expectedOccurrence: "Pod/api-7d9f-1/4",
+			expectedGroupKey:   "payments/Pod/api-7d9f-1/FailedMount/0",
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		f := testCase.pod.Failures[0]
+		if key := testCase.pod.DedupKey(f); key != testCase.expectedKey {
+			t.Errorf("Expected the key %v but received %v", testCase.expectedKey, key)
+		}
+
+		if occurrence := testCase.pod.Occurrence(f); occurrence != testCase.expectedOccurrence {
+			t.Errorf("Expected the occurrence %v but received %v", testCase.expectedOccurrence, occurrence)
+		}
+
+		if key := testCase.pod.GroupKey(); key != testCase.expectedGroupKey {
+			t.Errorf("Expected the group key %v but received %v", testCase.expectedGroupKey, key)
+		}
+	}
+
+}
+
+// TestIncidentFailure tests that an incident is turned back into the failure its silences are matched against.
+func TestIncidentFailure(t *testing.T) {
+
+	testSuite := map[string]struct {
+		incident Incident
+		expected PodStatusInformation
+	}{
+		"A workload with an owner should keep its kind and name": {
+			incident: Incident{Namespace: "payments", Workload: "Deployment/api", Causes: []string{"OOMKilled"}, Labels: map[string]string{"tier": "web"}},
+			expected: PodStatusInformation{Namespace: "payments", OwnerKind: "Deployment", OwnerName: "api", Labels: map[string]string{"tier": "web"},
+				Failures: []ContainerFailure{{Reason: "OOMKilled"}}},
+		},
+		"A bare pod should be the pod and a cause without a reason its exit code": {
+			incident: Incident{Namespace: "payments", Workload: "Pod/debug", Causes: []string{"Error", "exit code 2"}},
+			expected: PodStatusInformation{Namespace: "payments", PodName: "debug", Failures: []ContainerFailure{{Reason: "Error"}, {ExitCode: 2}}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if p := testCase.incident.Failure(); !reflect.DeepEqual(p, testCase.expected) {
+			t.Errorf("Expected the failure %+v but received %+v", testCase.expected, p)
+		}
 	}
 
 }
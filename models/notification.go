@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +21,12 @@ type NotificationHandler interface {
 	Notify(NotificationDetails) error
 }
 
+// Flusher is implemented by the handlers that buffer notifications instead of sending them straight away. Flush is called before
+// the handler is discarded, on shutdown or when the config is reloaded, and waits at most timeout for the buffer to be sent.
+type Flusher interface {
+	Flush(timeout time.Duration) error
+}
+
 // NotificationDetails is an struct that holds fields used by the Notify() method for all of the structs that satisfy the handler NotificationHandler.
 // For example body is used for slack and STDOUT/Default whereas Properties is used by applicationinsights.
 type NotificationDetails struct {
@@ -57,6 +65,12 @@ type SlackFields struct {
 	Value string `json:"value"`
 }
 
+// Webhook is a struct that holds the URL the notifications are posted to as json, the body is the same json that is printed to STDOUT.
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
 // STDOUT is a small struct used to hold a json payload thats printed to the screen.
 type STDOUT struct {
 	Body string
@@ -80,6 +94,18 @@ func (a *ApplicationInsights) Init(c *Config) error {
 
 }
 
+// Init copies the webhook URL from the config into 'w', an error is returned if it is abscent.
+func (w *Webhook) Init(c *Config) error {
+
+	if c.Notification.WebhookURL == "" {
+		return fmt.Errorf("missing webhook url")
+	}
+
+	w.URL = c.Notification.WebhookURL
+	w.client = &http.Client{Timeout: 10 * time.Second}
+	return nil
+}
+
 // Init loads the slack config from the *Config into 's'
 // An error is returned if one or more of these values is abscent
 func (s *Slack) Init(c *Config) error {
@@ -113,6 +139,18 @@ func (a ApplicationInsights) Notify(details NotificationDetails) error {
 
 }
 
+// Flush closes the telemetry channel, waiting for the buffered events to be submitted. The client can not be used once it is flushed.
+func (a ApplicationInsights) Flush(timeout time.Duration) error {
+
+	select {
+	case <-a.client.Channel().Close(timeout):
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v waiting for the application insights events to be sent", timeout)
+	}
+
+}
+
 // Notify is a method on Slack that posts the message to slack.
 func (s Slack) Notify(details NotificationDetails) error {
 
@@ -149,6 +187,30 @@ func (s Slack) Notify(details NotificationDetails) error {
 	return nil
 }
 
+// Notify posts the json body of the notification to the webhook, such as the webhook of a paging service.
+func (w Webhook) Notify(details NotificationDetails) error {
+
+	request, err := http.NewRequest("POST", w.URL, bytes.NewBuffer(details.body))
+	if err != nil {
+		return fmt.Errorf("encountered an error creating request : %v", err)
+	}
+
+	request.Header.Add("Content-Type", "application/json")
+	response, err := w.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("unable to perform POST request : %v", err)
+	}
+
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("the webhook returned %v", response.Status)
+	}
+
+	return nil
+}
+
 // Notify prints the message to STDOUT.
 func (s STDOUT) Notify(details NotificationDetails) error {
 
@@ -176,12 +238,88 @@ func BuildBody(handler NotificationHandler, p PodStatusInformation) (Notificatio
 	nDetails.properties = make(map[string]string)
 
 	nDetails.properties["Pod"] = p.PodName
-	nDetails.properties["Container"] = p.ContainerName
 	nDetails.properties["Namespace"] = p.Namespace
-	nDetails.properties["Image"] = p.Image
-	nDetails.properties["RunTime"] = fmt.Sprintf("%v until %v", p.StartedAt, p.FinishedAt)
-	nDetails.properties["FailureReason"] = podErrorReason(p)
-	nDetails.properties["ExitCode"] = podErrorCode(p)
+
+	if p.OwnerKind != "" {
+		nDetails.properties["OwnerKind"] = p.OwnerKind
+		nDetails.properties["OwnerName"] = p.OwnerName
+	}
+
+	if p.NodeName != "" {
+		nDetails.properties["Node"] = p.NodeName
+	}
+
+	if p.Severity != "" {
+		nDetails.properties["Severity"] = p.Severity
+	}
+
+	if p.Repeats > 0 {
+		nDetails.properties["Repeats"] = strconv.Itoa(p.Repeats)
+		nDetails.properties["RepeatWindow"] = formatWindow(p.RepeatWindow)
+	}
+
+	if p.Node != nil {
+		nDetails.properties["NodeZone"] = p.Node.Zone
+		nDetails.properties["NodeRegion"] = p.Node.Region
+		nDetails.properties["NodeInstanceType"] = p.Node.InstanceType
+		nDetails.properties["NodeReady"] = p.Node.Ready
+		nDetails.properties["NodeMemoryPressure"] = p.Node.MemoryPressure
+		nDetails.properties["NodeDiskPressure"] = p.Node.DiskPressure
+	}
+
+	// Every failure is carried as JSON, the first failure is also flattened into the properties that existed before a pod
+	// could carry more than one failed container.
+	failures, _ := json.Marshal(p.Failures)
+	nDetails.properties["Failures"] = string(failures)
+
+	names := []string{}
+	for _, f := range p.Failures {
+		names = append(names, f.ContainerName)
+	}
+	nDetails.properties["FailedContainers"] = strings.Join(names, ", ")
+
+	if len(p.Failures) == 0 {
+		return nDetails, nil
+	}
+
+	f := p.Failures[0]
+	nDetails.properties["Container"] = f.ContainerName
+	nDetails.properties["Image"] = f.Image
+	nDetails.properties["RunTime"] = fmt.Sprintf("%v until %v", p.StartedAt, f.FinishedAt)
+	nDetails.properties["FailureReason"] = podErrorReason(f)
+	nDetails.properties["ExitCode"] = podErrorCode(f)
+
+	if f.Logs != "" {
+		nDetails.properties["Logs"] = f.Logs
+	}
+
+	if f.RestartCount > 0 {
+		nDetails.properties["RestartCount"] = strconv.Itoa(int(f.RestartCount))
+	}
+
+	if f.Reason == CrashLoopBackOff {
+		nDetails.properties["BackOff"] = f.Message
+		nDetails.properties["LastTerminationReason"] = f.LastTerminationReason
+	}
+
+	if f.InitContainerOrder > 0 {
+		nDetails.properties["InitContainer"] = fmt.Sprintf("%v of %v", f.InitContainerOrder, f.InitContainerCount)
+	}
+
+	if f.IsImagePull() {
+		nDetails.properties["Registry"] = f.Registry
+		nDetails.properties["PullMessage"] = f.Message
+		nDetails.properties["ImagePullSecrets"] = strings.Join(p.ImagePullSecrets, ", ")
+		delete(nDetails.properties, "ExitCode")
+	}
+
+	if p.InvolvedObject != "" {
+		nDetails.properties["InvolvedObject"] = p.InvolvedObject
+		nDetails.properties["EventReason"] = f.Reason
+		nDetails.properties["EventMessage"] = f.Message
+		nDetails.properties["EventCount"] = strconv.Itoa(int(p.Count))
+		delete(nDetails.properties, "ExitCode")
+	}
 
 	return nDetails, nil
 
@@ -192,16 +330,35 @@ func BuildBody(handler NotificationHandler, p PodStatusInformation) (Notificatio
 // The marshalled 's' is returned to the caller.
 func BuildSlackBody(s *Slack, p PodStatusInformation) ([]byte, error) {
 
-	color := "danger"
-	errorDetails := podErrorCode(p)
-	reason := podErrorReason(p)
+	color := severityColor(p.Severity)
+
+	var msg string
+	if p.InvolvedObject != "" {
+		msg = podEventMessage(p)
+	} else {
+		msg = fmt.Sprintf("The pod : *%v*%v in namespace *%v* has encountered an error.", p.PodName, podOwnerMessage(p), p.Namespace)
+		if len(p.Failures) > 1 {
+			msg = fmt.Sprintf("The pod : *%v*%v in namespace *%v* has encountered an error in *%v* containers.", p.PodName, podOwnerMessage(p), p.Namespace, len(p.Failures))
+		}
+
+		msg += podNodeMessage(p)
 
-	// time.Format returns a string, to get out of having another field in the struct we format it here in line.
-	msg := fmt.Sprintf("The pod : *%v* has encountered an error.\n\nThe container is : *%v*\nWhich is running image : *%v*.\nThe error information is below.\n\n\n"+
-		"> %v\n> %v\n> The pod ran from : *%v until %v*", p.PodName, p.ContainerName, p.Image, reason, errorDetails,
-		p.StartedAt.Format(time.Stamp), p.FinishedAt.Format(time.Stamp))
+		for _, f := range p.Failures {
+			msg += containerFailureMessage(p, f)
+		}
+	}
 
-	s.Attachment = []SlackAttachments{
+	if p.Repeats > 0 {
+		msg += fmt.Sprintf("\n\n> It failed *%v* more time(s) in the last %v", p.Repeats, formatWindow(p.RepeatWindow))
+	}
+
+	if p.Severity != "" {
+		msg += fmt.Sprintf("\n\n> Severity : *%v*", p.Severity)
+	}
+
+	// The body is built on a copy of 's', the handler is shared by every goroutine that sends a notification
+	body := *s
+	body.Attachment = []SlackAttachments{
 		SlackAttachments{
 			Fallback: msg,
 			Color:    color,
@@ -210,8 +367,536 @@ func BuildSlackBody(s *Slack, p PodStatusInformation) ([]byte, error) {
 		},
 	}
 
-	slackMsg, _ := json.Marshal(s)
+	// The channel annotation only changes the channel of this message, 's' keeps the channel from the config
+	if p.Channel != "" {
+		body.Channel = p.Channel
+	}
+
+	slackMsg, _ := json.Marshal(body)
+
+	return slackMsg, nil
+
+}
+
+// BuildSummaryBody builds a single notification for a list of pods, it is used to report the pods that were already failed when
+// Hubbub started. Slack receives a message listing each pod, the other handlers receive the pods as json.
+func BuildSummaryBody(handler NotificationHandler, pods []PodStatusInformation) (NotificationDetails, error) {
+
+	nDetails := NotificationDetails{}
+	title := fmt.Sprintf("%v pod(s) were already failed when Hubbub started", len(pods))
+
+	if s, ok := handler.(*Slack); ok {
+		var err error
+		nDetails.body, err = BuildSlackSummaryBody(s, title, pods)
+		if err != nil {
+			return nDetails, err
+		}
+		return nDetails, nil
+	}
+
+	nDetails.body, _ = json.Marshal(struct {
+		Summary string
+		Pods    []PodStatusInformation
+	}{Summary: title, Pods: pods})
+
+	names := []string{}
+	for _, p := range pods {
+		names = append(names, p.Namespace+"/"+p.PodName)
+	}
+
+	podsJSON, _ := json.Marshal(pods)
+	nDetails.properties = map[string]string{
+		"Summary":    title,
+		"FailedPods": strings.Join(names, ", "),
+		"Pods":       string(podsJSON),
+	}
+
+	return nDetails, nil
+}
+
+// BuildSlackSummaryBody builds the slack payload for BuildSummaryBody, a line for each pod with its failed containers.
+func BuildSlackSummaryBody(s *Slack, title string, pods []PodStatusInformation) ([]byte, error) {
+
+	msg := title + " :\n"
+	for _, p := range pods {
+
+		containers := []string{}
+		for _, f := range p.Failures {
+			containers = append(containers, failureSummary(f))
+		}
+
+		msg += fmt.Sprintf("\n> *%v*%v in namespace *%v* : %v", p.PodName, podOwnerMessage(p), p.Namespace, strings.Join(containers, ", "))
+	}
+
+	body := *s
+	body.Attachment = []SlackAttachments{
+		SlackAttachments{
+			Fallback: msg,
+			Color:    "warning",
+			Title:    s.Title,
+			Field:    []SlackFields{SlackFields{Value: msg}},
+		},
+	}
+
+	slackMsg, _ := json.Marshal(body)
+
+	return slackMsg, nil
+}
+
+// FailureGroup is a set of pods that share a GroupKey(), the replicas of a workload that failed with the same reason and exit code.
+// Counts is the number of failures seen for each pod, keyed by the pod name.
+type FailureGroup struct {
+	Pods   []PodStatusInformation
+	Counts map[string]int
+}
+
+// Failures returns the number of failures seen across every pod in the group.
+func (g FailureGroup) Failures() int {
+
+	total := 0
+	for _, p := range g.Pods {
+		total += g.Counts[p.PodName]
+	}
+
+	return total
+}
+
+// severest returns the most severe pod in the group, the first of them if more than one pod has that severity.
+func (g FailureGroup) severest() PodStatusInformation {
+
+	severest := g.Pods[0]
+	for _, p := range g.Pods[1:] {
+		if SeverityRank(p.Severity) > SeverityRank(severest.Severity) {
+			severest = p
+		}
+	}
+
+	return severest
+}
+
+// BuildGroupBody builds a single notification for a FailureGroup. Slack receives a message naming the workload and the failure
+// followed by a line for each pod and the details of the first pod, the other handlers receive the pods as json. The channel and
+// severity are taken from the most severe pod, which is the first pod unless a severity rule matched the replicas differently.
+func BuildGroupBody(handler NotificationHandler, g FailureGroup) (NotificationDetails, error) {
+
+	nDetails := NotificationDetails{}
+	if len(g.Pods) == 0 {
+		return nDetails, fmt.Errorf("the group has no pods")
+	}
+
+	if s, ok := handler.(*Slack); ok {
+		var err error
+		nDetails.body, err = BuildSlackGroupBody(s, g)
+		if err != nil {
+			return nDetails, err
+		}
+		return nDetails, nil
+	}
+
+	first := g.Pods[0]
+	title := fmt.Sprintf("%v pod(s)%v in namespace %v failed %v time(s)", len(g.Pods), strings.Replace(podOwnerMessage(first), "*", "", -1),
+		first.Namespace, g.Failures())
+
+	nDetails.body, _ = json.Marshal(struct {
+		Summary  string
+		Failures int
+		Counts   map[string]int
+		Pods     []PodStatusInformation
+	}{Summary: title, Failures: g.Failures(), Counts: g.Counts, Pods: g.Pods})
+
+	names := []string{}
+	for _, p := range g.Pods {
+		names = append(names, p.PodName)
+	}
+
+	podsJSON, _ := json.Marshal(g.Pods)
+	nDetails.properties = map[string]string{
+		"Summary":      title,
+		"Namespace":    first.Namespace,
+		"PodCount":     strconv.Itoa(len(g.Pods)),
+		"FailureCount": strconv.Itoa(g.Failures()),
+		"FailedPods":   strings.Join(names, ", "),
+		"Pods":         string(podsJSON),
+	}
+
+	if first.OwnerKind != "" {
+		nDetails.properties["OwnerKind"] = first.OwnerKind
+		nDetails.properties["OwnerName"] = first.OwnerName
+	}
+
+	if severity := g.severest().Severity; severity != "" {
+		nDetails.properties["Severity"] = severity
+	}
+
+	if len(first.Failures) > 0 {
+		nDetails.properties["FailureReason"] = first.Failures[0].Reason
+		nDetails.properties["ExitCode"] = strconv.Itoa(first.Failures[0].ExitCode)
+	}
+
+	return nDetails, nil
+}
+
+// BuildSlackGroupBody builds the slack payload for BuildGroupBody.
+func BuildSlackGroupBody(s *Slack, g FailureGroup) ([]byte, error) {
+
+	first := g.Pods[0]
+
+	failures := []string{}
+	for _, f := range first.Failures {
+		summary := failureSummary(f)
+		if f.Reason != "" && f.ExitCode != 0 {
+			summary += fmt.Sprintf(" (exit code %v)", f.ExitCode)
+		}
+		failures = append(failures, summary)
+	}
+
+	msg := fmt.Sprintf("*%v* pods%v in namespace *%v* have failed *%v* time(s) with : %v\n", len(g.Pods), podOwnerMessage(first), first.Namespace,
+		g.Failures(), strings.Join(failures, ", "))
+
+	for _, p := range g.Pods {
+		msg += fmt.Sprintf("\n> *%v* failed *%v* time(s)", p.PodName, g.Counts[p.PodName])
+		if p.NodeName != "" {
+			msg += fmt.Sprintf(" on the node *%v*", p.NodeName)
+		}
+	}
+
+	msg += fmt.Sprintf("\n\nThe details of *%v* are below.", first.PodName)
+	if first.InvolvedObject != "" {
+		msg += "\n\n" + podEventMessage(first)
+	} else {
+		for _, f := range first.Failures {
+			msg += containerFailureMessage(first, f)
+		}
+	}
+
+	severest := g.severest()
+	if severest.Severity != "" {
+		msg += fmt.Sprintf("\n\n> Severity : *%v*", severest.Severity)
+	}
+
+	body := *s
+	body.Attachment = []SlackAttachments{
+		SlackAttachments{
+			Fallback: msg,
+			Color:    severityColor(severest.Severity),
+			Title:    s.Title,
+			Field:    []SlackFields{SlackFields{Value: msg}},
+		},
+	}
+
+	if severest.Channel != "" {
+		body.Channel = severest.Channel
+	}
+
+	slackMsg, _ := json.Marshal(body)
 
 	return slackMsg, nil
+}
+
+// BuildResolvedBody builds the notification sent once the workload of an incident has recovered. Slack receives a green message
+// with the time to recovery, the other handlers receive the incident as json along with the time to recovery, which is also in the
+// Duration property in seconds.
+func BuildResolvedBody(handler NotificationHandler, i Incident) (NotificationDetails, error) {
+
+	nDetails := NotificationDetails{}
+
+	if s, ok := handler.(*Slack); ok {
+		var err error
+		nDetails.body, err = BuildSlackResolvedBody(s, i)
+		if err != nil {
+			return nDetails, err
+		}
+		return nDetails, nil
+	}
+
+	title := fmt.Sprintf("%v in namespace %v has recovered after %v", i.Workload, i.Namespace, formatWindow(i.TimeToRecovery()))
+
+	nDetails.body, _ = json.Marshal(struct {
+		Resolved       string
+		TimeToRecovery string
+		Incident       Incident
+	}{Resolved: title, TimeToRecovery: formatWindow(i.TimeToRecovery()), Incident: i})
+
+	nDetails.properties = map[string]string{
+		"Status":         "Resolved",
+		"Summary":        title,
+		"Namespace":      i.Namespace,
+		"Workload":       i.Workload,
+		"Causes":         strings.Join(i.Causes, ", "),
+		"Opened":         i.Opened.String(),
+		"Resolved":       i.Resolved.String(),
+		"Duration":       strconv.FormatFloat(i.TimeToRecovery().Seconds(), 'f', 0, 64),
+		"TimeToRecovery": formatWindow(i.TimeToRecovery()),
+		"Notifications":  strconv.Itoa(i.Notifications),
+	}
+
+	if i.Severity != "" {
+		nDetails.properties["Severity"] = i.Severity
+	}
+
+	return nDetails, nil
+}
+
+// BuildSlackResolvedBody builds the slack payload for BuildResolvedBody, it is posted to the channel the failures were posted to.
+func BuildSlackResolvedBody(s *Slack, i Incident) ([]byte, error) {
+
+	causes := []string{}
+	for _, c := range i.Causes {
+		causes = append(causes, "`"+c+"`")
+	}
+
+	kind, name := i.Workload, ""
+	if parts := strings.SplitN(i.Workload, "/", 2); len(parts) == 2 {
+		kind, name = parts[0], parts[1]
+	}
+
+	msg := fmt.Sprintf("The %v : *%v* in namespace *%v* has recovered after *%v*.\n\n> It failed with : %v\n> *%v* notification(s) were sent from *%v until %v*",
+		kind, name, i.Namespace, formatWindow(i.TimeToRecovery()), strings.Join(causes, ", "), i.Notifications,
+		i.Opened.Format(time.Stamp), i.Resolved.Format(time.Stamp))
+
+	body := *s
+	body.Attachment = []SlackAttachments{
+		SlackAttachments{
+			Fallback: msg,
+			Color:    "good",
+			Title:    s.Title,
+			Field:    []SlackFields{SlackFields{Value: msg}},
+		},
+	}
+
+	if i.Channel != "" {
+		body.Channel = i.Channel
+	}
+
+	slackMsg, _ := json.Marshal(body)
+
+	return slackMsg, nil
+}
+
+// BuildEscalationBody builds the notification for step (starting at 1) of the escalation policy, sent when the workload of the incident
+// is still failing after the delay of the step. Slack receives a red message naming the workload, how long it has been failing and the
+// step, the other handlers receive the incident as json along with the policy and step, the time since the first notification is in the
+// Duration property in seconds.
+func BuildEscalationBody(handler NotificationHandler, i Incident, policy EscalationPolicy, step int) (NotificationDetails, error) {
+
+	nDetails := NotificationDetails{}
+	if step < 1 || step > len(policy.Steps) {
+		return nDetails, fmt.Errorf("the escalation policy %v has no step %v", policy.Name, step)
+	}
+
+	failing := policy.Steps[step-1].Delay()
+
+	if s, ok := handler.(*Slack); ok {
+		var err error
+		nDetails.body, err = BuildSlackEscalationBody(s, i, policy, step, failing)
+		if err != nil {
+			return nDetails, err
+		}
+		return nDetails, nil
+	}
+
+	title := fmt.Sprintf("%v in namespace %v is still failing %v after it was first notified", i.Workload, i.Namespace, formatWindow(failing))
+
+	nDetails.body, _ = json.Marshal(struct {
+		Escalated string
+		Policy    string
+		Step      int
+		Steps     int
+		Incident  Incident
+	}{Escalated: title, Policy: policy.Name, Step: step, Steps: len(policy.Steps), Incident: i})
+
+	nDetails.properties = map[string]string{
+		"Status":        "Escalated",
+		"Summary":       title,
+		"Namespace":     i.Namespace,
+		"Workload":      i.Workload,
+		"Causes":        strings.Join(i.Causes, ", "),
+		"Opened":        i.Opened.String(),
+		"Duration":      strconv.FormatFloat(failing.Seconds(), 'f', 0, 64),
+		"Notifications": strconv.Itoa(i.Notifications),
+		"Policy":        policy.Name,
+		"Step":          strconv.Itoa(step),
+	}
+
+	if i.Severity != "" {
+		nDetails.properties["Severity"] = i.Severity
+	}
+
+	return nDetails, nil
+}
+
+// BuildSlackEscalationBody builds the slack payload for BuildEscalationBody, it is posted to the channel of the handler of the step
+// rather than the channel the failures were posted to.
+func BuildSlackEscalationBody(s *Slack, i Incident, policy EscalationPolicy, step int, failing time.Duration) ([]byte, error) {
+
+	causes := []string{}
+	for _, c := range i.Causes {
+		causes = append(causes, "`"+c+"`")
+	}
+
+	kind, name := i.Workload, ""
+	if parts := strings.SplitN(i.Workload, "/", 2); len(parts) == 2 {
+		kind, name = parts[0], parts[1]
+	}
+
+	msg := fmt.Sprintf("The %v : *%v* in namespace *%v* is still failing *%v* after it was first notified.\n\n> It is failing with : %v\n> *%v* notification(s) have been sent since *%v*\n> This is step *%v* of *%v* of the escalation policy *%v*",
+		kind, name, i.Namespace, formatWindow(failing), strings.Join(causes, ", "), i.Notifications, i.Opened.Format(time.Stamp), step,
+		len(policy.Steps), policy.Name)
+
+	if i.Severity != "" {
+		msg += fmt.Sprintf("\n\n> Severity : *%v*", i.Severity)
+	}
+
+	body := *s
+	body.Attachment = []SlackAttachments{
+		SlackAttachments{
+			Fallback: msg,
+			Color:    "danger",
+			Title:    s.Title,
+			Field:    []SlackFields{SlackFields{Value: msg}},
+		},
+	}
+
+	slackMsg, _ := json.Marshal(body)
+
+	return slackMsg, nil
+}
+
+// failureSummary returns a short description of a failed container for the summary, e.g. "api `CrashLoopBackOff`".
+func failureSummary(f ContainerFailure) string {
+
+	if f.Reason != "" {
+		return fmt.Sprintf("%v `%v`", f.ContainerName, f.Reason)
+	}
+
+	return fmt.Sprintf("%v `exit code %v`", f.ContainerName, f.ExitCode)
+}
+
+// severityColor returns the color of the slack attachment for a severity, a failure without a known severity is shown as danger.
+func severityColor(severity string) string {
+
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "#439FE0"
+	}
+
+	return "danger"
+}
+
+// formatWindow formats the window the repeats of a failure were counted over, rounded to the minute once it is over a minute (e.g. 10m).
+func formatWindow(d time.Duration) string {
+
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// podOwnerMessage returns the owning workload for the slack message, e.g. " of Deployment *api*", or an empty string if the pod has no owner.
+func podOwnerMessage(p PodStatusInformation) string {
+
+	if p.OwnerKind == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" of %v *%v*", p.OwnerKind, p.OwnerName)
+}
+
+// podNodeMessage returns the node the pod ran on and the state of the node for the slack message, or an empty string if the pod was never scheduled.
+func podNodeMessage(p PodStatusInformation) string {
+
+	if p.NodeName == "" {
+		return ""
+	}
+
+	msg := fmt.Sprintf("\nIt ran on the node : *%v*", p.NodeName)
+	if p.Node == nil {
+		return msg
+	}
+
+	topology := []string{}
+	for _, label := range []string{p.Node.InstanceType, p.Node.Zone, p.Node.Region} {
+		if label != "" {
+			topology = append(topology, label)
+		}
+	}
+	if len(topology) > 0 {
+		msg += fmt.Sprintf(" (%v)", strings.Join(topology, ", "))
+	}
+
+	return msg + fmt.Sprintf("\n> Ready : *%v*, MemoryPressure : *%v*, DiskPressure : *%v*", p.Node.Ready, p.Node.MemoryPressure, p.Node.DiskPressure)
+}
+
+// containerFailureMessage returns the part of the slack message that describes a single failed container in the pod.
+func containerFailureMessage(p PodStatusInformation, f ContainerFailure) string {
+
+	var msg string
+	switch {
+	case f.Reason == CrashLoopBackOff:
+		msg = podCrashLoopMessage(f)
+	case f.IsImagePull():
+		msg = podImagePullMessage(p, f)
+	default:
+		// time.Format returns a string, to get out of having another field in the struct we format it here in line.
+		msg = fmt.Sprintf("\n\nThe container is : *%v*\nWhich is running image : *%v*.\nThe error information is below.\n\n\n"+
+			"> %v\n> %v\n> The pod ran from : *%v until %v*", f.ContainerName, f.Image, podErrorReason(f), podErrorCode(f),
+			p.StartedAt.Format(time.Stamp), f.FinishedAt.Format(time.Stamp))
+	}
+
+	if f.InitContainerOrder > 0 {
+		msg += fmt.Sprintf("\n> The failure happened during initialization, in init container *%v of %v* running image *%v*", f.InitContainerOrder, f.InitContainerCount, f.Image)
+	}
+
+	if f.Logs != "" {
+		// a code block can not be nested, so any backticks in the logs that would close it early are swapped out
+		msg += fmt.Sprintf("\n\nThe last lines logged by *%v* :\n```%v```", f.ContainerName, strings.Replace(f.Logs, "```", "` ` `", -1))
+	}
+
+	return msg
+}
+
+// podEventMessage returns the slack message for a Warning event loaded via LoadEvent().
+func podEventMessage(p PodStatusInformation) string {
+
+	msg := fmt.Sprintf("Kubernetes reported a warning event for *%v*%v in namespace *%v*.\n\n", p.InvolvedObject, podOwnerMessage(p), p.Namespace)
+	for _, f := range p.Failures {
+
+		if f.ContainerName != "" {
+			msg += fmt.Sprintf("The container is : *%v*\n", f.ContainerName)
+		}
+
+		msg += fmt.Sprintf("\n> Reason : `%v`\n> Message : `%v`\n> The event has been seen *%v* time(s) from : *%v until %v*",
+			f.Reason, f.Message, p.Count, p.StartedAt.Format(time.Stamp), f.FinishedAt.Format(time.Stamp))
+	}
+
+	return msg
+}
+
+// podCrashLoopMessage returns the slack message for a container stuck in CrashLoopBackOff.
+func podCrashLoopMessage(f ContainerFailure) string {
+
+	lastTermination := podErrorCode(f)
+	if f.LastTerminationReason != "" {
+		lastTermination = fmt.Sprintf("%v (%v)", strings.TrimSpace(lastTermination), f.LastTerminationReason)
+	}
+
+	return fmt.Sprintf("\n\nThe container : *%v* is stuck in CrashLoopBackOff.\nWhich is running image : *%v*.\n"+
+		"It has been restarted *%v* time(s), the last termination is below.\n\n\n> %v\n> Back-off : `%v`\n> The container last finished at : *%v*",
+		f.ContainerName, f.Image, f.RestartCount, lastTermination, f.Message, f.FinishedAt.Format(time.Stamp))
+}
+
+// podImagePullMessage returns the slack message for a container that is unable to pull its image.
+func podImagePullMessage(p PodStatusInformation, f ContainerFailure) string {
+
+	secrets := "none"
+	if len(p.ImagePullSecrets) > 0 {
+		secrets = strings.Join(p.ImagePullSecrets, ", ")
+	}
 
+	return fmt.Sprintf("\n\nThe container : *%v* is unable to pull its image.\nThe image is : *%v*\nFrom the registry : *%v*\n\n\n"+
+		"> Reason : `%v`\n> Kubelet message : `%v`\n> Image pull secrets : *%v*", f.ContainerName, f.Image, f.Registry,
+		f.Reason, f.Message, secrets)
 }
@@ -3,8 +3,11 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -26,6 +29,8 @@ func TestNotificationInit(t *testing.T) {
 		// app insights specific
 		eventTitle string
 		key        string
+		// webhook specific
+		webhookURL string
 		// the expected response (error)
 		expectedResponse string
 	}{
@@ -39,7 +44,7 @@ func TestNotificationInit(t *testing.T) {
 		},
 		"(s *Slack) Init() will throw an error due to missing fields": {
 			notificationType: "slack",
-			expectedResponse: "Missing slack token or channel",
+			expectedResponse: "missing slack token or channel",
 		},
 		"(s *ApplicationInsights) Init() will throw an error due to missing fields": {
 			notificationType: "ai",
@@ -50,6 +55,14 @@ func TestNotificationInit(t *testing.T) {
 			eventTitle:       "#broTalk",
 			key:              "as8932OsdAS89DQR54FDas8932OsdAS89DQR54FD",
 		},
+		"(w *Webhook) Init() will throw an error due to missing fields": {
+			notificationType: "webhook",
+			expectedResponse: "missing webhook url",
+		},
+		"(w *Webhook) Init() will return nil": {
+			notificationType: "webhook",
+			webhookURL:       "https://events.pager.local/hubbub",
+		},
 	}
 
 	for testName, testCase := range testSuite {
@@ -62,11 +75,14 @@ func TestNotificationInit(t *testing.T) {
 		fakeConf.Notification.SlackChannel = testCase.channel
 		fakeConf.Notification.AppInsightsKey = testCase.key
 		fakeConf.Notification.CustomEventTitle = testCase.eventTitle
+		fakeConf.Notification.WebhookURL = testCase.webhookURL
 
 		if testCase.notificationType == "slack" {
 			notification = new(Slack)
 		} else if testCase.notificationType == "ai" {
 			notification = new(ApplicationInsights)
+		} else if testCase.notificationType == "webhook" {
+			notification = new(Webhook)
 		} else {
 			notification = new(STDOUT)
 		}
@@ -145,13 +161,16 @@ func TestBuildBody(t *testing.T) {
 		bodyHandler := testHandler
 
 		p.Namespace = "default"
-		p.Image = "hubbub"
 		p.PodName = "hubbubTestPod-" + testCase.notificationType
-		p.ContainerName = "hubbubTestContainer"
-		p.ExitCode = testCase.exitCode
-		p.Reason = testCase.podReason
-		p.Message = testCase.podMessage
 		p.Seen = time.Now()
+		p.Failures = []ContainerFailure{{
+			ContainerName: "hubbubTestContainer",
+			Image:         "hubbub",
+			FinishedAt:    time.Now(),
+			ExitCode:      testCase.exitCode,
+			Reason:        testCase.podReason,
+			Message:       testCase.podMessage,
+		}}
 		c.Notification.SlackWebHook = "google.com"
 		c.Notification.SlackTitle = "Oh no!"
 		tl, _ := time.LoadLocation(c.TimeZone)
@@ -173,8 +192,9 @@ func TestBuildBody(t *testing.T) {
 			json.Unmarshal(msgInBytes.body, &pCheck)
 
 			// avoiding deepequal here due to issues with timestamps
-			if p.Namespace == pCheck.Namespace && p.ContainerName == pCheck.ContainerName && p.Reason == pCheck.Reason &&
-				p.ExitCode == pCheck.ExitCode && p.Message == pCheck.Message {
+			f := p.Failures[0]
+			if p.Namespace == pCheck.Namespace && len(pCheck.Failures) == 1 && f.ContainerName == pCheck.Failures[0].ContainerName &&
+				f.Reason == pCheck.Failures[0].Reason && f.ExitCode == pCheck.Failures[0].ExitCode && f.Message == pCheck.Failures[0].Message {
 				t.Logf("Unmarshalled byte array from BuildBody matches the provided PodStatusInformation struct\n")
 			} else {
 				t.Errorf("The byte array returned from (s STDOUT) BuildBody did not match the provided PodStatusInformation struct!\n%v != %v", p, pCheck)
@@ -182,9 +202,10 @@ func TestBuildBody(t *testing.T) {
 
 		} else {
 
-			errorDetails := strconv.Itoa(p.ExitCode)
-			podMsg := fmt.Sprintf("The pod : *%v* has encountered an error.\n\nThe container is : *%v*\nWhich is running image : *%v*.\n",
-				p.PodName, p.ContainerName, p.Image)
+			f := p.Failures[0]
+			errorDetails := strconv.Itoa(f.ExitCode)
+			podMsg := fmt.Sprintf("The pod : *%v* in namespace *%v* has encountered an error.\n\nThe container is : *%v*\nWhich is running image : *%v*.\n",
+				p.PodName, p.Namespace, f.ContainerName, f.Image)
 
 			slackBody := Slack{}
 			json.Unmarshal(msgInBytes.body, &slackBody)
@@ -206,16 +227,16 @@ func TestBuildBody(t *testing.T) {
 				t.Errorf("Expected Notification.Slackattachment.fallback to contain the predefined message containg the pod and container name but it was not found.\nThe message was %v", podMsg)
 			}
 
-			if p.Reason != "" && !strings.Contains(msg, p.Reason) {
+			if f.Reason != "" && !strings.Contains(msg, f.Reason) {
 				t.Errorf("Expected Notification.Slackattachment.fallback to contain the supplied pod failure reason '%v' but it was not found. %v", testCase.podReason, msg)
 			}
 
-			if p.Message != "" && !strings.Contains(msg, p.Message) {
+			if f.Message != "" && !strings.Contains(msg, f.Message) {
 				t.Errorf("Expected Notification.Slackattachment.fallback to contain the supplied pod failure message '%v' but it was not found", testCase.podMessage)
 			}
 
-			if !strings.Contains(msg, p.StartedAt.Format(time.Stamp)) || !strings.Contains(msg, p.FinishedAt.Format(time.Stamp)) {
-				t.Errorf("Expected Notification.Slackattachment.fallback to contain the correct time stamps '%v' and '%v' but one or more of these were not found", p.StartedAt.Format(time.Stamp), p.FinishedAt.Format(time.Stamp))
+			if !strings.Contains(msg, p.StartedAt.Format(time.Stamp)) || !strings.Contains(msg, f.FinishedAt.Format(time.Stamp)) {
+				t.Errorf("Expected Notification.Slackattachment.fallback to contain the correct time stamps '%v' and '%v' but one or more of these were not found", p.StartedAt.Format(time.Stamp), f.FinishedAt.Format(time.Stamp))
 			}
 
 			if msg != slackBody.Attachment[0].Field[0].Value {
@@ -226,9 +247,781 @@ func TestBuildBody(t *testing.T) {
 	}
 }
 
-// ExampleSTDOUTNotify is an Example that verifies that the notify function
+// TestBuildBodySeverity tests that the severity sets the color of the slack message and is carried in the properties and json.
+func TestBuildBodySeverity(t *testing.T) {
+
+	testSuite := map[string]struct {
+		severity      string
+		expectedColor string
+	}{
+		"A failure without a severity should be danger": {expectedColor: "danger"},
+		"A critical failure should be danger":           {severity: SeverityCritical, expectedColor: "danger"},
+		"A warning should be warning":                   {severity: SeverityWarning, expectedColor: "warning"},
+		"An info failure should be blue":                {severity: SeverityInfo, expectedColor: "#439FE0"},
+		"An unknown severity should be danger":          {severity: "high", expectedColor: "danger"},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		p := PodStatusInformation{
+			Namespace: "payments",
+			PodName:   "api-1",
+			Severity:  testCase.severity,
+			Failures:  []ContainerFailure{{ContainerName: "api", Reason: "Error", ExitCode: 143}},
+		}
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, _ := BuildBody(slack, p)
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+
+		if slackBody.Attachment[0].Color != testCase.expectedColor {
+			t.Errorf("Expected the color %v but received %v", testCase.expectedColor, slackBody.Attachment[0].Color)
+		}
+
+		details, _ := BuildBody(new(STDOUT), p)
+		if details.properties["Severity"] != testCase.severity {
+			t.Errorf("Expected the Severity property '%v' but received '%v'", testCase.severity, details.properties["Severity"])
+		}
+
+		pCheck := PodStatusInformation{}
+		json.Unmarshal(details.body, &pCheck)
+		if pCheck.Severity != testCase.severity {
+			t.Errorf("Expected the severity '%v' in the json but received '%v'", testCase.severity, pCheck.Severity)
+		}
+	}
+
+}
+
+// TestBuildSlackBodyConcurrent builds two bodies at the same time on a single slack handler, as the watches do, and verifies
+// that each message carries its own pod and that the handler is not changed. Run it with -race to catch writes to the handler.
+func TestBuildSlackBodyConcurrent(t *testing.T) {
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+
+	slack := new(Slack)
+	slack.Init(&c)
+
+	pods := []PodStatusInformation{
+		{Namespace: "payments", PodName: "api-1", Failures: []ContainerFailure{{ContainerName: "api", Reason: "Error", ExitCode: 143}}},
+		{Namespace: "orders", PodName: "worker-1", Failures: []ContainerFailure{{ContainerName: "worker", Reason: "OOMKilled", ExitCode: 137}}},
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, len(pods))
+	for i := range pods {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				bodies[i], _ = BuildSlackBody(slack, pods[i])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, p := range pods {
+		slackBody := Slack{}
+		json.Unmarshal(bodies[i], &slackBody)
+
+		if len(slackBody.Attachment) != 1 || !strings.Contains(slackBody.Attachment[0].Fallback, p.PodName) {
+			t.Errorf("Expected the message of %v to name the pod but received %+v", p.PodName, slackBody.Attachment)
+		}
+	}
+
+	if slack.Attachment != nil {
+		t.Errorf("Expected the handler to be left without an attachment but received %+v", slack.Attachment)
+	}
+
+}
+
+// TestBuildBodyWording verifies that failures with their own wording (warning events, CrashLoopBackOff etc..) contain the
+// expected strings in the slack message and carry the expected properties used by application insights.
+func TestBuildBodyWording(t *testing.T) {
+
+	testSuite := map[string]struct {
+		pod                PodStatusInformation
+		expectedStrings    []string
+		expectedProperties map[string]string
+		expectedChannel    string
+	}{
+		"A warning event should contain the involved object, reason, message and count": {
+			pod: PodStatusInformation{
+				Namespace:      "hubbub",
+				PodName:        "api-1",
+				InvolvedObject: "Pod/api-1",
+				Count:          7,
+				Failures: []ContainerFailure{{
+					ContainerName: "api",
+					Reason:        "FailedMount",
+					Message:       "MountVolume.SetUp failed for volume \"secrets\"",
+				}},
+			},
+			expectedStrings: []string{"*Pod/api-1*", "*hubbub*", "`FailedMount`", "MountVolume.SetUp failed", "*7* time(s)", "*api*"},
+			expectedProperties: map[string]string{
+				"InvolvedObject": "Pod/api-1",
+				"EventReason":    "FailedMount",
+				"EventMessage":   "MountVolume.SetUp failed for volume \"secrets\"",
+				"EventCount":     "7",
+			},
+		},
+		"A CrashLoopBackOff should contain the restart count, last exit code and back-off message": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-1",
+				Failures: []ContainerFailure{{
+					ContainerName:         "api",
+					Image:                 "hubbub:1",
+					Reason:                CrashLoopBackOff,
+					Message:               "back-off 5m0s restarting failed container=api",
+					ExitCode:              139,
+					RestartCount:          12,
+					LastTerminationReason: "Error",
+				}},
+			},
+			expectedStrings: []string{"stuck in CrashLoopBackOff", "*api*", "*12* time(s)", "139", "Segmentation fault.", "(Error)", "`back-off 5m0s restarting failed container=api`"},
+			expectedProperties: map[string]string{
+				"RestartCount":          "12",
+				"BackOff":               "back-off 5m0s restarting failed container=api",
+				"LastTerminationReason": "Error",
+			},
+		},
+		"An image pull failure should contain the image, registry, kubelet message and pull secrets": {
+			pod: PodStatusInformation{
+				Namespace:        "hubbub",
+				PodName:          "api-1",
+				ImagePullSecrets: []string{"acr-pull", "backup-pull"},
+				Failures: []ContainerFailure{{
+					ContainerName: "api",
+					Image:         "myregistry.azurecr.io/api:v2",
+					Registry:      "myregistry.azurecr.io",
+					Reason:        "ErrImagePull",
+					Message:       "manifest unknown",
+				}},
+			},
+			expectedStrings: []string{"unable to pull its image", "*myregistry.azurecr.io/api:v2*", "*myregistry.azurecr.io*", "`ErrImagePull`", "`manifest unknown`", "*acr-pull, backup-pull*"},
+			expectedProperties: map[string]string{
+				"Registry":         "myregistry.azurecr.io",
+				"PullMessage":      "manifest unknown",
+				"ImagePullSecrets": "acr-pull, backup-pull",
+			},
+		},
+		"A failed init container should say the failure happened during initialization": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-1",
+				Failures: []ContainerFailure{{
+					ContainerName:      "migrate",
+					Image:              "hubbub-migrations:1",
+					Reason:             "Error",
+					ExitCode:           2,
+					InitContainerOrder: 2,
+					InitContainerCount: 3,
+				}},
+			},
+			expectedStrings: []string{"*migrate*", "during initialization", "*2 of 3*", "*hubbub-migrations:1*"},
+			expectedProperties: map[string]string{
+				"InitContainer": "2 of 3",
+				"Image":         "hubbub-migrations:1",
+			},
+		},
+		"The owning workload should be in the message and the properties": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-7c9f8d6b5-xk2lp",
+				OwnerKind: "Deployment",
+				OwnerName: "api",
+				Failures: []ContainerFailure{{
+					ContainerName: "api",
+					Image:         "hubbub:1",
+					Reason:        "Error",
+					ExitCode:      1,
+				}},
+			},
+			expectedStrings: []string{"The pod : *api-7c9f8d6b5-xk2lp* of Deployment *api* in namespace *hubbub*"},
+			expectedProperties: map[string]string{
+				"OwnerKind": "Deployment",
+				"OwnerName": "api",
+			},
+		},
+		"The node and its conditions should be in the message and the properties": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-1",
+				NodeName:  "aks-nodepool1-0",
+				Node:      &NodeInformation{Zone: "eastus-1", Region: "eastus", InstanceType: "Standard_D4s_v3", Ready: "True", MemoryPressure: "True", DiskPressure: "False"},
+				Failures: []ContainerFailure{{
+					ContainerName: "api",
+					Image:         "hubbub:1",
+					Reason:        "OOMKilled",
+					ExitCode:      137,
+				}},
+			},
+			expectedStrings: []string{"*aks-nodepool1-0* (Standard_D4s_v3, eastus-1, eastus)", "MemoryPressure : *True*", "DiskPressure : *False*", "Ready : *True*"},
+			expectedProperties: map[string]string{
+				"Node":               "aks-nodepool1-0",
+				"NodeZone":           "eastus-1",
+				"NodeRegion":         "eastus",
+				"NodeInstanceType":   "Standard_D4s_v3",
+				"NodeMemoryPressure": "True",
+			},
+		},
+		"The channel and severity annotations should route and label the message": {
+			pod: PodStatusInformation{
+				Namespace: "payments",
+				PodName:   "ledger-1",
+				Channel:   "#payments",
+				Severity:  "critical",
+				Failures: []ContainerFailure{{
+					ContainerName: "ledger",
+					Image:         "ledger:1",
+					Reason:        "Error",
+					ExitCode:      1,
+				}},
+			},
+			expectedStrings:    []string{"Severity : *critical*"},
+			expectedProperties: map[string]string{"Severity": "critical"},
+			expectedChannel:    "#payments",
+		},
+		"Suppressed repeats should be counted in the message": {
+			pod: PodStatusInformation{
+				Namespace:    "payments",
+				PodName:      "ledger-1",
+				Repeats:      7,
+				RepeatWindow: 10*time.Minute + 20*time.Second,
+				Failures: []ContainerFailure{{
+					ContainerName: "ledger",
+					Image:         "ledger:1",
+					Reason:        "Error",
+					ExitCode:      1,
+				}},
+			},
+			expectedStrings:    []string{"It failed *7* more time(s) in the last 10m"},
+			expectedProperties: map[string]string{"Repeats": "7", "RepeatWindow": "10m"},
+		},
+		"Container logs should be in a code block and a property": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-1",
+				Failures: []ContainerFailure{{
+					ContainerName: "api",
+					Image:         "hubbub:1",
+					Reason:        "Error",
+					ExitCode:      1,
+					Logs:          "starting\npanic: nil map",
+				}},
+			},
+			expectedStrings: []string{"The last lines logged by *api*", "```starting\npanic: nil map```"},
+			expectedProperties: map[string]string{
+				"Logs": "starting\npanic: nil map",
+			},
+		},
+		"A pod with two failed containers should contain both of them": {
+			pod: PodStatusInformation{
+				Namespace: "hubbub",
+				PodName:   "api-1",
+				Failures: []ContainerFailure{
+					{
+						ContainerName: "api",
+						Image:         "hubbub:1",
+						Reason:        "OOMKilled",
+						ExitCode:      137,
+					},
+					{
+						ContainerName: "envoy",
+						Image:         "envoyproxy/envoy:v1.12",
+						Reason:        "Error",
+						ExitCode:      1,
+					},
+				},
+			},
+			expectedStrings: []string{"*2* containers", "*api*", "*hubbub:1*", "`OOMKilled`", "137", "*envoy*", "*envoyproxy/envoy:v1.12*", "Application Error."},
+			expectedProperties: map[string]string{
+				"Container":        "api",
+				"FailedContainers": "api, envoy",
+			},
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+	c.Notification.SlackChannel = "Testing"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		p := testCase.pod
+		p.StartedAt = time.Now()
+		for i := range p.Failures {
+			p.Failures[i].FinishedAt = time.Now()
+		}
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, _ := BuildBody(slack, p)
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		if expectedChannel := testCase.expectedChannel; expectedChannel != "" && (slackBody.Channel != expectedChannel || slack.Channel != c.Notification.SlackChannel) {
+			t.Errorf("Expected the message to be posted to %v without changing the handler, but received %v and %v", expectedChannel, slackBody.Channel, slack.Channel)
+		}
+
+		details, _ := BuildBody(new(STDOUT), p)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+
+		failures := []ContainerFailure{}
+		if err := json.Unmarshal([]byte(details.properties["Failures"]), &failures); err != nil || len(failures) != len(p.Failures) {
+			t.Errorf("Expected the Failures property to carry %v failures but received %v (%v)", len(p.Failures), details.properties["Failures"], err)
+		}
+	}
+}
+
+// TestBuildSummaryBody tests the summary of the pods that were already failed when Hubbub started.
+func TestBuildSummaryBody(t *testing.T) {
+
+	testSuite := map[string]struct {
+		pods               []PodStatusInformation
+		expectedStrings    []string
+		expectedProperties map[string]string
+	}{
+		"Every pod and failed container should be listed": {
+			pods: []PodStatusInformation{
+				{
+					Namespace: "payments",
+					PodName:   "api-1",
+					OwnerKind: "Deployment",
+					OwnerName: "api",
+					Failures:  []ContainerFailure{{ContainerName: "api", Reason: CrashLoopBackOff}, {ContainerName: "proxy", ExitCode: 137}},
+				},
+				{
+					Namespace: "orders",
+					PodName:   "worker-1",
+					Failures:  []ContainerFailure{{ContainerName: "worker", Reason: "ErrImagePull"}},
+				},
+			},
+			expectedStrings: []string{
+				"2 pod(s) were already failed",
+				"*api-1* of Deployment *api* in namespace *payments* : api `CrashLoopBackOff`, proxy `exit code 137`",
+				"*worker-1* in namespace *orders* : worker `ErrImagePull`",
+			},
+			expectedProperties: map[string]string{
+				"Summary":    "2 pod(s) were already failed when Hubbub started",
+				"FailedPods": "payments/api-1, orders/worker-1",
+			},
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+	c.Notification.SlackChannel = "Testing"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, _ := BuildSummaryBody(slack, testCase.pods)
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		details, _ := BuildSummaryBody(new(STDOUT), testCase.pods)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+
+		pods := []PodStatusInformation{}
+		if err := json.Unmarshal([]byte(details.properties["Pods"]), &pods); err != nil || len(pods) != len(testCase.pods) {
+			t.Errorf("Expected the Pods property to carry %v pods but received %v (%v)", len(testCase.pods), details.properties["Pods"], err)
+		}
+	}
+}
+
+// TestBuildGroupBody tests the single notification sent for the replicas of a workload that failed the same way.
+func TestBuildGroupBody(t *testing.T) {
+
+	testSuite := map[string]struct {
+		group              FailureGroup
+		expectedStrings    []string
+		expectedProperties map[string]string
+		expectedChannel    string
+		expectedColor      string
+	}{
+		"Every pod should be listed with its count": {
+			group: FailureGroup{
+				Pods: []PodStatusInformation{
+					{
+						Namespace: "payments",
+						PodName:   "api-1",
+						OwnerKind: "Deployment",
+						OwnerName: "api",
+						NodeName:  "node-1",
+						Channel:   "#payments",
+						Failures:  []ContainerFailure{{ContainerName: "api", Image: "api:2", Reason: "OOMKilled", ExitCode: 137}},
+					},
+					{
+						Namespace: "payments",
+						PodName:   "api-2",
+						OwnerKind: "Deployment",
+						OwnerName: "api",
+						Failures:  []ContainerFailure{{ContainerName: "api", Image: "api:2", Reason: "OOMKilled", ExitCode: 137}},
+					},
+				},
+				Counts: map[string]int{"api-1": 2, "api-2": 1},
+			},
+			expectedStrings: []string{
+				"*2* pods of Deployment *api* in namespace *payments* have failed *3* time(s) with : api `OOMKilled` (exit code 137)",
+				"> *api-1* failed *2* time(s) on the node *node-1*",
+				"> *api-2* failed *1* time(s)",
+				"The details of *api-1* are below.",
+				"Which is running image : *api:2*",
+			},
+			expectedProperties: map[string]string{
+				"Summary":       "2 pod(s) of Deployment api in namespace payments failed 3 time(s)",
+				"PodCount":      "2",
+				"FailureCount":  "3",
+				"FailedPods":    "api-1, api-2",
+				"OwnerName":     "api",
+				"FailureReason": "OOMKilled",
+				"ExitCode":      "137",
+			},
+			expectedChannel: "#payments",
+			expectedColor:   "danger",
+		},
+		"A group of warning events should describe the event": {
+			group: FailureGroup{
+				Pods: []PodStatusInformation{
+					{
+						Namespace:      "payments",
+						PodName:        "api-1",
+						OwnerKind:      "Deployment",
+						OwnerName:      "api",
+						InvolvedObject: "Pod/api-1",
+						Count:          3,
+						Failures:       []ContainerFailure{{Reason: "FailedMount", Message: "secret not found"}},
+					},
+					{
+						Namespace:      "payments",
+						PodName:        "api-2",
+						OwnerKind:      "Deployment",
+						OwnerName:      "api",
+						InvolvedObject: "Pod/api-2",
+						Count:          1,
+						Failures:       []ContainerFailure{{Reason: "FailedMount", Message: "secret not found"}},
+					},
+				},
+				Counts: map[string]int{"api-1": 1, "api-2": 1},
+			},
+			expectedStrings: []string{
+				"The details of *api-1* are below.",
+				"Kubernetes reported a warning event for *Pod/api-1*",
+				"> Reason : `FailedMount`",
+			},
+			expectedProperties: map[string]string{"FailureReason": "FailedMount"},
+			expectedChannel:    "Testing",
+			expectedColor:      "danger",
+		},
+		"The most severe pod should set the severity and channel": {
+			group: FailureGroup{
+				Pods: []PodStatusInformation{
+					{
+						Namespace: "payments",
+						PodName:   "api-1",
+						Severity:  SeverityInfo,
+						Failures:  []ContainerFailure{{ContainerName: "api", Reason: "CrashLoopBackOff", RestartCount: 2}},
+					},
+					{
+						Namespace: "payments",
+						PodName:   "api-2",
+						Severity:  SeverityWarning,
+						Channel:   "#payments-alerts",
+						Failures:  []ContainerFailure{{ContainerName: "api", Reason: "CrashLoopBackOff", RestartCount: 8}},
+					},
+				},
+				Counts: map[string]int{"api-1": 1, "api-2": 1},
+			},
+			expectedStrings:    []string{"> Severity : *warning*", "The details of *api-1* are below."},
+			expectedProperties: map[string]string{"Severity": SeverityWarning},
+			expectedChannel:    "#payments-alerts",
+			expectedColor:      "warning",
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+	c.Notification.SlackChannel = "Testing"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, _ := BuildGroupBody(slack, testCase.group)
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		if slackBody.Channel != testCase.expectedChannel {
+			t.Errorf("Expected the channel %v but received %v", testCase.expectedChannel, slackBody.Channel)
+		}
+
+		if slackBody.Attachment[0].Color != testCase.expectedColor {
+			t.Errorf("Expected the color %v but received %v", testCase.expectedColor, slackBody.Attachment[0].Color)
+		}
+
+		details, _ := BuildGroupBody(new(STDOUT), testCase.group)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+	}
+
+	if _, err := BuildGroupBody(new(STDOUT), FailureGroup{}); err == nil {
+		t.Errorf("Expected an error building the body of an empty group")
+	}
+}
+
+// TestBuildResolvedBody tests the notification sent once the workload of an incident has recovered.
+func TestBuildResolvedBody(t *testing.T) {
+
+	opened := time.Date(2019, time.December, 12, 10, 0, 0, 0, time.UTC)
+
+	testSuite := map[string]struct {
+		incident           Incident
+		expectedStrings    []string
+		expectedProperties map[string]string
+	}{
+		"The time to recovery and the causes should be included": {
+			incident: Incident{
+				Namespace:     "payments",
+				Workload:      "Deployment/api",
+				Causes:        []string{"OOMKilled", "exit code 1"},
+				Opened:        opened,
+				Resolved:      opened.Add(12 * time.Minute),
+				Notifications: 3,
+			},
+			expectedStrings: []string{
+				"The Deployment : *api* in namespace *payments* has recovered after *12m*.",
+				"> It failed with : `OOMKilled`, `exit code 1`",
+				"> *3* notification(s) were sent",
+			},
+			expectedProperties: map[string]string{
+				"Status":         "Resolved",
+				"Workload":       "Deployment/api",
+				"Causes":         "OOMKilled, exit code 1",
+				"Duration":       "720",
+				"TimeToRecovery": "12m",
+				"Notifications":  "3",
+			},
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+	c.Notification.SlackChannel = "Testing"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, _ := BuildResolvedBody(slack, testCase.incident)
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		if color := slackBody.Attachment[0].Color; color != "good" {
+			t.Errorf("Expected the resolved message to be green but received %v", color)
+		}
+
+		details, _ := BuildResolvedBody(new(STDOUT), testCase.incident)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+	}
+}
+
+// TestBuildEscalationBody tests the notification sent for a step of an escalation policy.
+func TestBuildEscalationBody(t *testing.T) {
+
+	opened := time.Date(2019, time.December, 12, 10, 0, 0, 0, time.UTC)
+	policy := EscalationPolicy{Name: "pager", Steps: []EscalationStep{{After: 600}, {After: 1800}}}
+
+	testSuite := map[string]struct {
+		incident           Incident
+		step               int
+		expectedStrings    []string
+		expectedProperties map[string]string
+		expectError        bool
+	}{
+		"The time since the first notification and the step should be included": {
+			incident: Incident{
+				Namespace:     "payments",
+				Workload:      "Deployment/api",
+				Causes:        []string{"OOMKilled"},
+				Opened:        opened,
+				Notifications: 2,
+				Channel:       "#payments",
+				Severity:      SeverityCritical,
+			},
+			step: 2,
+			expectedStrings: []string{
+				"The Deployment : *api* in namespace *payments* is still failing *30m* after it was first notified.",
+				"> It is failing with : `OOMKilled`",
+				"> This is step *2* of *2* of the escalation policy *pager*",
+				"> Severity : *critical*",
+			},
+			expectedProperties: map[string]string{
+				"Status":        "Escalated",
+				"Workload":      "Deployment/api",
+				"Duration":      "1800",
+				"Notifications": "2",
+				"Policy":        "pager",
+				"Step":          "2",
+				"Severity":      SeverityCritical,
+			},
+		},
+		"A step the policy does not have should fail": {
+			incident:    Incident{Namespace: "payments", Workload: "Deployment/api", Opened: opened},
+			step:        3,
+			expectError: true,
+		},
+	}
+
+	c := testConfigFile
+	c.Notification.SlackWebHook = "google.com"
+	c.Notification.SlackChannel = "#oncall"
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		slack := new(Slack)
+		slack.Init(&c)
+		msgInBytes, err := BuildEscalationBody(slack, testCase.incident, policy, testCase.step)
+		if (err != nil) != testCase.expectError {
+			t.Fatalf("Expected an error : %v, but received %v", testCase.expectError, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		slackBody := Slack{}
+		json.Unmarshal(msgInBytes.body, &slackBody)
+		msg := slackBody.Attachment[0].Fallback
+
+		for _, expected := range testCase.expectedStrings {
+			if !strings.Contains(msg, expected) {
+				t.Errorf("Expected the slack message to contain %v but it was not found.\n%v", expected, msg)
+			}
+		}
+
+		if slackBody.Channel != "#oncall" {
+			t.Errorf("Expected the escalation to be posted to the channel of its handler but received %v", slackBody.Channel)
+		}
+
+		details, _ := BuildEscalationBody(new(STDOUT), testCase.incident, policy, testCase.step)
+		for k, v := range testCase.expectedProperties {
+			if details.properties[k] != v {
+				t.Errorf("Expected the property %v to be %v but received %v", k, v, details.properties[k])
+			}
+		}
+	}
+}
+
+// TestWebhookNotify tests that the webhook posts the json body of the notification and reports the responses that are not a success.
+func TestWebhookNotify(t *testing.T) {
+
+	testSuite := map[string]struct {
+		status      int
+		expectError bool
+	}{
+		"An accepted notification should return nil":        {status: http.StatusAccepted},
+		"A notification that is refused should be an error": {status: http.StatusBadRequest, expectError: true},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		received := PodStatusInformation{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(testCase.status)
+		}))
+
+		c := testConfigFile
+		c.Notification.WebhookURL = server.URL
+
+		webhook := new(Webhook)
+		webhook.Init(&c)
+		details, _ := BuildBody(webhook, PodStatusInformation{Namespace: "payments", PodName: "api-1"})
+
+		if err := webhook.Notify(details); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error : %v, but received %v", testCase.expectError, err)
+		}
+
+		if received.PodName != "api-1" {
+			t.Errorf("Expected the webhook to receive the pod as json but received %v", received)
+		}
+
+		server.Close()
+	}
+}
+
+// ExampleSTDOUT_Notify is an Example that verifies that the notify function
 // on STDOUT is printing the correct byte array to STDOUT
-func ExampleSTDOUTNotify() {
+func ExampleSTDOUT_Notify() {
 
 	h := testHandler
 	h = new(STDOUT)
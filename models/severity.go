@@ -0,0 +1,167 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// The severities a failure is classified as, from the most to the least severe.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// severityRanks orders the severities, see SeverityRank().
+var severityRanks = map[string]int{SeverityCritical: 3, SeverityWarning: 2, SeverityInfo: 1}
+
+// SeverityRank returns the rank of the severity, a higher rank is more severe. A failure without a severity, or with one set by an
+// annotation that is not known, ranks with SeverityCritical as it was always notified as such.
+func SeverityRank(severity string) int {
+
+	if rank, ok := severityRanks[severity]; ok {
+		return rank
+	}
+
+	return severityRanks[SeverityCritical]
+}
+
+// validSeverity reports if the severity is one of the known severities.
+func validSeverity(severity string) bool {
+	_, ok := severityRanks[severity]
+	return ok
+}
+
+// SeverityRule assigns its Severity to the failures it matches. Every field that is set must match, a rule with none set matches every
+// failure. Namespaces are glob patterns and Labels is a label selector for the labels of the pod. Reasons, ExitCodes and MinRestarts must
+// match the same failed container, a reason matches the reason the container failed with or the reason it last terminated with.
+type SeverityRule struct {
+	Severity    string   `json:"severity"`
+	Reasons     []string `json:"reasons,omitempty"`
+	ExitCodes   []int    `json:"exitCodes,omitempty"`
+	Namespaces  []string `json:"namespaces,omitempty"`
+	Labels      string   `json:"labels,omitempty"`
+	MinRestarts int32    `json:"minRestarts,omitempty"`
+}
+
+// Validate checks that the severity of the rule is known and that its patterns and label selector parse.
+func (r SeverityRule) Validate() error {
+
+	if !validSeverity(r.Severity) {
+		return fmt.Errorf("invalid severity '%v' in a severity rule, it must be '%v', '%v' or '%v'", r.Severity, SeverityCritical, SeverityWarning, SeverityInfo)
+	}
+
+	for _, pattern := range r.Namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern '%v' in a severity rule : %v", pattern, err)
+		}
+	}
+
+	if _, err := labels.Parse(r.Labels); err != nil {
+		return fmt.Errorf("invalid label selector '%v' in a severity rule : %v", r.Labels, err)
+	}
+
+	return nil
+}
+
+// Matches reports if the rule matches the failures in p.
+func (r SeverityRule) Matches(p PodStatusInformation) bool {
+
+	if len(r.Namespaces) > 0 {
+		matched := false
+		for _, pattern := range r.Namespaces {
+			if ok, _ := path.Match(pattern, p.Namespace); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if r.Labels != "" {
+		selector, err := labels.Parse(r.Labels)
+		if err != nil || !selector.Matches(labels.Set(p.Labels)) {
+			return false
+		}
+	}
+
+	if len(r.Reasons) == 0 && len(r.ExitCodes) == 0 && r.MinRestarts == 0 {
+		return true
+	}
+
+	for _, f := range p.Failures {
+		if r.matchesReason(f) && r.matchesExitCode(f) && f.RestartCount >= r.MinRestarts {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesReason reports if the failure has one of the reasons of the rule.
+func (r SeverityRule) matchesReason(f ContainerFailure) bool {
+
+	if len(r.Reasons) == 0 {
+		return true
+	}
+
+	for _, reason := range r.Reasons {
+		if strings.EqualFold(reason, f.Reason) || (f.LastTerminationReason != "" && strings.EqualFold(reason, f.LastTerminationReason)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesExitCode reports if the failure has one of the exit codes of the rule.
+func (r SeverityRule) matchesExitCode(f ContainerFailure) bool {
+
+	if len(r.ExitCodes) == 0 {
+		return true
+	}
+
+	for _, code := range r.ExitCodes {
+		if code == f.ExitCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Classify returns the severity of the failures in p, the severity of the first rule that matches or the default severity when
+// none do. It is empty if there are no rules and no default.
+func (c *Config) Classify(p PodStatusInformation) string {
+
+	for _, rule := range c.Severity.Rules {
+		if rule.Matches(p) {
+			return rule.Severity
+		}
+	}
+
+	return c.Severity.Default
+}
+
+// SeverityNotified reports if a failure of the severity generates a notification, it must not be less severe than the minimum severity.
+func (c *Config) SeverityNotified(severity string) bool {
+	return c.Severity.Minimum == "" || SeverityRank(severity) >= SeverityRank(c.Severity.Minimum)
+}
+
+// Classify sets the severity of p from the severity rules in c, unless it was set with the hubbub.io/severity annotation. The
+// notification is routed to the channel of the severity unless the channel was set with the hubbub.io/channel annotation.
+func (p *PodStatusInformation) Classify(c *Config) {
+
+	if p.Severity == "" {
+		p.Severity = c.Classify(*p)
+	}
+
+	if p.Channel == "" {
+		p.Channel = c.Severity.Channels[p.Severity]
+	}
+}
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestSeverityRuleMatches tests that a severity rule only matches the failures that match every field it sets.
+func TestSeverityRuleMatches(t *testing.T) {
+
+	pod := PodStatusInformation{
+		Namespace: "payments",
+		PodName:   "api-7d9f-1",
+		Labels:    map[string]string{"app": "api", "tier": "backend"},
+		Failures: []ContainerFailure{
+			{ContainerName: "api", Reason: "CrashLoopBackOff", LastTerminationReason: "OOMKilled", ExitCode: 137, RestartCount: 6},
+			{ContainerName: "proxy", Reason: "Error", ExitCode: 143},
+		},
+	}
+
+	testSuite := map[string]struct {
+		rule        SeverityRule
+		expectMatch bool
+	}{
+		"A rule with no fields should match every failure": {
+			rule:        SeverityRule{Severity: SeverityWarning},
+			expectMatch: true,
+		},
+		"The namespaces should be glob patterns": {
+			rule:        SeverityRule{Severity: SeverityInfo, Namespaces: []string{"dev-*", "pay*"}},
+			expectMatch: true,
+		},
+		"Another namespace should not match": {
+			rule: SeverityRule{Severity: SeverityInfo, Namespaces: []string{"dev-*"}},
+		},
+		"The label selector should match the pod labels": {
+			rule:        SeverityRule{Severity: SeverityCritical, Labels: "tier=backend"},
+			expectMatch: true,
+		},
+		"A label selector that does not match should not match": {
+			rule: SeverityRule{Severity: SeverityCritical, Labels: "tier=batch"},
+		},
+		"The reason should match the last termination reason": {
+			rule:        SeverityRule{Severity: SeverityCritical, Reasons: []string{"oomkilled"}},
+			expectMatch: true,
+		},
+		"The exit code should match any failure": {
+			rule:        SeverityRule{Severity: SeverityInfo, ExitCodes: []int{143}},
+			expectMatch: true,
+		},
+		"The reason and restarts should match the same failure": {
+			rule:        SeverityRule{Severity: SeverityCritical, Reasons: []string{"CrashLoopBackOff"}, MinRestarts: 5},
+			expectMatch: true,
+		},
+		"A failure with fewer restarts should not match": {
+			rule: SeverityRule{Severity: SeverityCritical, Reasons: []string{"CrashLoopBackOff"}, MinRestarts: 10},
+		},
+		"An exit code and reason from different failures should not match": {
+			rule: SeverityRule{Severity: SeverityInfo, Reasons: []string{"Error"}, ExitCodes: []int{137}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if match := testCase.rule.Matches(pod); match != testCase.expectMatch {
+			t.Errorf("Expected the rule to match : %v, but received %v", testCase.expectMatch, match)
+		}
+	}
+
+}
+
+// TestClassify tests that the first matching rule sets the severity, that the annotations take precedence over the rules and
+// that the notification is routed to the channel of its severity.
+func TestClassify(t *testing.T) {
+
+	c := Config{}
+	c.Severity.Default = SeverityWarning
+	c.Severity.Rules = []SeverityRule{
+		{Severity: SeverityInfo, ExitCodes: []int{143}},
+		{Severity: SeverityCritical, Reasons: []string{"OOMKilled"}},
+		{Severity: SeverityWarning, Namespaces: []string{"payments"}},
+	}
+	c.Severity.Channels = map[string]string{SeverityCritical: "#oncall"}
+
+	testSuite := map[string]struct {
+		pod              PodStatusInformation
+		expectedSeverity string
+		expectedChannel  string
+	}{
+		"A SIGTERM should be classified as info": {
+			pod:              PodStatusInformation{Namespace: "payments", Failures: []ContainerFailure{{ExitCode: 143}}},
+			expectedSeverity: SeverityInfo,
+		},
+		"The first matching rule should set the severity": {
+			pod:              PodStatusInformation{Namespace: "payments", Failures: []ContainerFailure{{Reason: "OOMKilled", ExitCode: 137}}},
+			expectedSeverity: SeverityCritical,
+			expectedChannel:  "#oncall",
+		},
+		"A failure that matches no rule should use the default": {
+			pod:              PodStatusInformation{Namespace: "orders", Failures: []ContainerFailure{{Reason: "Error", ExitCode: 1}}},
+			expectedSeverity: SeverityWarning,
+		},
+		"The severity annotation should take precedence over the rules": {
+			pod:              PodStatusInformation{Namespace: "payments", Severity: SeverityCritical, Failures: []ContainerFailure{{ExitCode: 143}}},
+			expectedSeverity: SeverityCritical,
+			expectedChannel:  "#oncall",
+		},
+		"The channel annotation should take precedence over the severity channel": {
+			pod:              PodStatusInformation{Namespace: "payments", Channel: "#payments", Failures: []ContainerFailure{{Reason: "OOMKilled"}}},
+			expectedSeverity: SeverityCritical,
+			expectedChannel:  "#payments",
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		p := testCase.pod
+		p.Classify(&c)
+
+		if p.Severity != testCase.expectedSeverity {
+			t.Errorf("Expected the severity %v but received %v", testCase.expectedSeverity, p.Severity)
+		}
+
+		if p.Channel != testCase.expectedChannel {
+			t.Errorf("Expected the channel '%v' but received '%v'", testCase.expectedChannel, p.Channel)
+		}
+	}
+
+}
+
+// TestSeverityNotified tests that only the failures at or above the minimum severity are notified.
+func TestSeverityNotified(t *testing.T) {
+
+	testSuite := map[string]struct {
+		minimum      string
+		severity     string
+		expectNotify bool
+	}{
+		"Every severity should be notified without a minimum": {
+			severity:     SeverityInfo,
+			expectNotify: true,
+		},
+		"A severity below the minimum should not be notified": {
+			minimum:  SeverityWarning,
+			severity: SeverityInfo,
+		},
+		"The minimum severity should be notified": {
+			minimum:      SeverityWarning,
+			severity:     SeverityWarning,
+			expectNotify: true,
+		},
+		"A failure without a severity should be notified": {
+			minimum:      SeverityCritical,
+			expectNotify: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		c := Config{}
+		c.Severity.Minimum = testCase.minimum
+
+		if notify := c.SeverityNotified(testCase.severity); notify != testCase.expectNotify {
+			t.Errorf("Expected the severity %v to be notified : %v, but received %v", testCase.severity, testCase.expectNotify, notify)
+		}
+	}
+
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Silence suppresses the notifications for the failures it matches between Start and End, such as the workloads being worked
+// on during planned maintenance. Every field that is set must match, a silence with none set matches every failure. Namespace and
+// Workload are glob patterns, the workload is matched in the form Kind/Name (e.g. Deployment/api) or on its name alone. Labels is a
+// label selector for the labels of the pod. Reason and ExitCode must match the same failed container.
+type Silence struct {
+	ID        string    `json:"id,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Workload  string    `json:"workload,omitempty"`
+	Labels    string    `json:"labels,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	ExitCode  *int      `json:"exitCode,omitempty"`
+	Start     time.Time `json:"start,omitempty"`
+	End       time.Time `json:"end"`
+}
+
+// Validate checks that the patterns and the label selector of the silence parse and that it ends after it starts.
+func (s Silence) Validate() error {
+
+	if s.End.IsZero() {
+		return fmt.Errorf("the silence %v has no end time", s.ID)
+	}
+
+	if !s.End.After(s.Start) {
+		return fmt.Errorf("the silence %v ends (%v) before it starts (%v)", s.ID, s.End, s.Start)
+	}
+
+	for _, pattern := range []string{s.Namespace, s.Workload} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%v' in the silence %v : %v", pattern, s.ID, err)
+		}
+	}
+
+	if _, err := labels.Parse(s.Labels); err != nil {
+		return fmt.Errorf("invalid label selector '%v' in the silence %v : %v", s.Labels, s.ID, err)
+	}
+
+	return nil
+}
+
+// Active reports if the silence is in effect at 'now'.
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.Start) && now.Before(s.End)
+}
+
+// Matches reports if the silence is active and matches the failures in p.
+func (s Silence) Matches(p PodStatusInformation, now time.Time) bool {
+
+	if !s.Active(now) {
+		return false
+	}
+
+	if s.Namespace != "" {
+		if ok, _ := path.Match(s.Namespace, p.Namespace); !ok {
+			return false
+		}
+	}
+
+	if s.Workload != "" {
+		name := p.OwnerName
+		if p.OwnerKind == "" {
+			name = p.PodName
+		}
+
+		fullMatch, _ := path.Match(s.Workload, p.Workload())
+		nameMatch, _ := path.Match(s.Workload, name)
+		if !fullMatch && !nameMatch {
+			return false
+		}
+	}
+
+	if s.Labels != "" {
+		selector, err := labels.Parse(s.Labels)
+		if err != nil || !selector.Matches(labels.Set(p.Labels)) {
+			return false
+		}
+	}
+
+	if s.Reason == "" && s.ExitCode == nil {
+		return true
+	}
+
+	for _, f := range p.Failures {
+		if (s.Reason == "" || strings.EqualFold(s.Reason, f.Reason)) && (s.ExitCode == nil || *s.ExitCode == f.ExitCode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String describes the silence for the logs, e.g. "maintenance (database upgrade) until Dec 12 10:00:00".
+func (s Silence) String() string {
+
+	if s.Comment == "" {
+		return fmt.Sprintf("%v until %v", s.ID, s.End.Format(time.Stamp))
+	}
+
+	return fmt.Sprintf("%v (%v) until %v", s.ID, s.Comment, s.End.Format(time.Stamp))
+}
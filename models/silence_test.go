@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSilenceMatches tests that a silence only matches the failures that match every field it sets while it is active.
+func TestSilenceMatches(t *testing.T) {
+
+	now := time.Now()
+	oom := 137
+
+	pod := PodStatusInformation{
+		Namespace: "payments",
+		PodName:   "api-7d9f-1",
+		OwnerKind: "Deployment",
+		OwnerName: "api",
+		Labels:    map[string]string{"app": "api", "tier": "backend"},
+		Failures:  []ContainerFailure{{ContainerName: "api", Reason: "OOMKilled", ExitCode: 137}},
+	}
+
+	testSuite := map[string]struct {
+		silence     Silence
+		expectMatch bool
+	}{
+		"A silence with no fields should match every failure": {
+			silence:     Silence{End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"A silence that has ended should not match": {
+			silence: Silence{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+		},
+		"A silence that has not started should not match": {
+			silence: Silence{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+		},
+		"The namespace should be a glob pattern": {
+			silence:     Silence{Namespace: "pay*", End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"Another namespace should not match": {
+			silence: Silence{Namespace: "orders", End: now.Add(time.Hour)},
+		},
+		"The workload should match on its kind and name": {
+			silence:     Silence{Workload: "Deployment/api", End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"The workload should match on its name": {
+			silence:     Silence{Workload: "api", End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"Another workload kind should not match": {
+			silence: Silence{Workload: "StatefulSet/api", End: now.Add(time.Hour)},
+		},
+		"The label selector should match the pod labels": {
+			silence:     Silence{Labels: "tier=backend,app in (api, web)", End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"A label selector that does not match should not match": {
+			silence: Silence{Labels: "tier=frontend", End: now.Add(time.Hour)},
+		},
+		"The reason and exit code should match the same failure": {
+			silence:     Silence{Reason: "oomkilled", ExitCode: &oom, End: now.Add(time.Hour)},
+			expectMatch: true,
+		},
+		"Another reason should not match": {
+			silence: Silence{Namespace: "payments", Reason: "Error", End: now.Add(time.Hour)},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if match := testCase.silence.Matches(pod, now); match != testCase.expectMatch {
+			t.Errorf("Expected the silence to match : %v, but received %v", testCase.expectMatch, match)
+		}
+	}
+
+}
+
+// TestSilenceValidate tests that Validate() rejects a silence without a valid window, pattern or label selector.
+func TestSilenceValidate(t *testing.T) {
+
+	now := time.Now()
+
+	testSuite := map[string]struct {
+		silence     Silence
+		expectError bool
+	}{
+		"A silence with an end should be valid": {
+			silence: Silence{End: now.Add(time.Hour)},
+		},
+		"A silence without an end should fail validation": {
+			silence:     Silence{Namespace: "payments"},
+			expectError: true,
+		},
+		"A silence that ends before it starts should fail validation": {
+			silence:     Silence{Start: now, End: now.Add(-time.Hour)},
+			expectError: true,
+		},
+		"An invalid pattern should fail validation": {
+			silence:     Silence{Workload: "[api", End: now.Add(time.Hour)},
+			expectError: true,
+		},
+		"An invalid label selector should fail validation": {
+			silence:     Silence{Labels: "app in (", End: now.Add(time.Hour)},
+			expectError: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if err := testCase.silence.Validate(); (err != nil) != testCase.expectError {
+			t.Errorf("Expected an error from Validate() : %v, but received %v", testCase.expectError, err)
+		}
+	}
+
+}
@@ -0,0 +1,135 @@
+package silence
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// apiPath is the path the silences are served on, a single silence is at apiPath/id.
+const apiPath = "/silences"
+
+// TokenEnv is the env variable holding the bearer token of the silence API, it is read by Hubbub and the silence subcommand.
+const TokenEnv = "HUBBUB_API_TOKEN"
+
+// NewHandler returns the http.Handler of the silence API, every request must carry token as a bearer token.
+//
+//	GET    /silences      lists the silences that have not ended
+//	POST   /silences      creates a silence from the json body and returns it with its id
+//	DELETE /silences/{id} deletes a silence created through the API
+func NewHandler(r *Registry, token string) http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(apiPath, func(w http.ResponseWriter, req *http.Request) {
+
+		switch req.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, r.List())
+		case http.MethodPost:
+			s := models.Silence{}
+			if err := json.NewDecoder(req.Body).Decode(&s); err != nil {
+				http.Error(w, fmt.Sprintf("invalid silence : %v", err), http.StatusBadRequest)
+				return
+			}
+
+			created, err := r.Add(s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			fmt.Printf("Created the silence %v\n", created)
+			writeJSON(w, http.StatusCreated, created)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc(apiPath+"/", func(w http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.TrimPrefix(req.URL.Path, apiPath+"/")
+		if err := r.Delete(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		fmt.Printf("Deleted the silence %v\n", id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+
+		if !authorized(req, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// authorized reports if the request carries token as its bearer token, an empty token authorizes nothing.
+func authorized(req *http.Request, token string) bool {
+
+	header := req.Header.Get("Authorization")
+	if token == "" || !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, "Bearer ")), []byte(token)) == 1
+}
+
+// Serve serves the silence API on address until ctx is done, the requests are authorized with token. An address without a host,
+// such as :8080, is served on localhost only, the API has to be exposed explicitly e.g. with 0.0.0.0:8080.
+func Serve(ctx context.Context, address, token string, r *Registry) error {
+
+	if token == "" {
+		return fmt.Errorf("unable to serve the silence API : a token is required, set it with -api-token or %v", TokenEnv)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("unable to serve the silence API : invalid address %v : %v", address, err)
+	}
+	if host == "" {
+		address = net.JoinHostPort("localhost", port)
+	}
+
+	server := &http.Server{Addr: address, Handler: NewHandler(r, token)}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Serving the silence API on %v...\n", address)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("unable to serve the silence API : %v", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes v as the json body of the response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
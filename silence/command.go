@@ -0,0 +1,145 @@
+package silence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// commandUsage is printed when the silence subcommand is run without a known action.
+const commandUsage = `usage : hubbub silence <add|list|delete> [flags]
+
+  add     creates a silence, e.g. hubbub silence add -namespace payments -workload api -duration 2h -comment "database upgrade"
+  list    lists the silences that have not ended
+  delete  deletes a silence created through the API, e.g. hubbub silence delete 3f9c2a1b7d4e`
+
+// RunCommand runs the silence subcommand, args are the arguments that follow it. The silences are managed through the API of a
+// running Hubbub (see Serve), the token of the API is taken from -token or the env variable TokenEnv. The output is written to out.
+func RunCommand(args []string, out io.Writer) error {
+
+	if len(args) == 0 {
+		return errors.New(commandUsage)
+	}
+
+	flags := flag.NewFlagSet("silence "+args[0], flag.ContinueOnError)
+	api := flags.String("api", "http://localhost:8080", "The address of the silence API of a running Hubbub.")
+	token := flags.String("token", os.Getenv(TokenEnv), "The bearer token of the silence API, the default is "+TokenEnv+".")
+
+	switch args[0] {
+	case "add":
+		s := models.Silence{}
+		flags.StringVar(&s.Namespace, "namespace", "", "The namespaces to silence, a glob pattern.")
+		flags.StringVar(&s.Workload, "workload", "", "The workloads to silence, a glob pattern matched on Kind/Name or the name.")
+		flags.StringVar(&s.Labels, "labels", "", "A label selector for the pods to silence.")
+		flags.StringVar(&s.Reason, "reason", "", "The failure reason to silence, e.g. OOMKilled.")
+		flags.StringVar(&s.Comment, "comment", "", "Why the silence was created.")
+		exitCode := flags.String("exit-code", "", "The exit code to silence.")
+		start := flags.String("start", "", "When the silence starts (RFC3339), the default is now.")
+		duration := flags.Duration("duration", time.Hour, "How long the silence lasts, from its start.")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if *exitCode != "" {
+			code, err := strconv.Atoi(*exitCode)
+			if err != nil {
+				return fmt.Errorf("invalid exit code %v", *exitCode)
+			}
+			s.ExitCode = &code
+		}
+
+		s.Start = time.Now()
+		if *start != "" {
+			var err error
+			if s.Start, err = time.Parse(time.RFC3339, *start); err != nil {
+				return fmt.Errorf("invalid start time %v : %v", *start, err)
+			}
+		}
+		s.End = s.Start.Add(*duration)
+
+		body, _ := json.Marshal(s)
+		created := models.Silence{}
+		if err := request(http.MethodPost, *api+apiPath, *token, body, http.StatusCreated, &created); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Created the silence %v\n", created)
+		return nil
+
+	case "list":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		silences := []models.Silence{}
+		if err := request(http.MethodGet, *api+apiPath, *token, nil, http.StatusOK, &silences); err != nil {
+			return err
+		}
+
+		for _, s := range silences {
+			fmt.Fprintf(out, "%v\n", s)
+		}
+		return nil
+
+	case "delete":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage : hubbub silence delete [-api address] <id>")
+		}
+
+		if err := request(http.MethodDelete, *api+apiPath+"/"+flags.Arg(0), *token, nil, http.StatusNoContent, nil); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Deleted the silence %v\n", flags.Arg(0))
+		return nil
+	}
+
+	return errors.New(commandUsage)
+}
+
+// request sends a request to the silence API with the bearer token and decodes the response into v, an error is returned if the
+// status is not expected.
+func request(method, url, token string, body []byte, expected int, v interface{}) error {
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("encountered an error creating request : %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach the silence API : %v", err)
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response body : %v", err)
+	}
+
+	if response.StatusCode != expected {
+		return fmt.Errorf("the silence API returned %v : %v", response.Status, strings.TrimSpace(string(content)))
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.Unmarshal(content, v)
+}
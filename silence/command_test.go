@@ -0,0 +1,110 @@
+package silence
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRunCommand tests the silence subcommand against the API served by NewHandler(), and that the API refuses a wrong token.
+func TestRunCommand(t *testing.T) {
+
+	r := NewRegistry()
+	server := httptest.NewServer(NewHandler(r, "secret"))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	if err := RunCommand([]string{"add", "-api", server.URL, "-token", "secret", "-namespace", "payments", "-exit-code", "137", "-duration", "2h", "-comment", "upgrade"}, out); err != nil {
+		t.Fatalf("Error adding the silence %v", err)
+	}
+
+	silences := r.List()
+	if len(silences) != 1 || silences[0].Namespace != "payments" || *silences[0].ExitCode != 137 || silences[0].End.Sub(silences[0].Start).Hours() != 2 {
+		t.Fatalf("Expected a two hour silence for exit code 137 in payments but received %+v", silences)
+	}
+
+	out.Reset()
+	if err := RunCommand([]string{"list", "-api", server.URL, "-token", "secret"}, out); err != nil || !strings.Contains(out.String(), silences[0].ID+" (upgrade)") {
+		t.Errorf("Expected the silence to be listed but received %v (%v)", out.String(), err)
+	}
+
+	if err := RunCommand([]string{"delete", "-api", server.URL, "-token", "secret", "missing"}, out); err == nil {
+		t.Errorf("Expected an error deleting a silence that does not exist")
+	}
+
+	if err := RunCommand([]string{"delete", "-api", server.URL, "-token", "secret", silences[0].ID}, out); err != nil || len(r.List()) != 0 {
+		t.Errorf("Expected the silence to be deleted but received %v", err)
+	}
+
+	if err := RunCommand([]string{"add", "-api", server.URL, "-token", "secret", "-duration", "-1h"}, out); err == nil {
+		t.Errorf("Expected the API to reject a silence that ends before it starts")
+	}
+
+	if err := RunCommand([]string{"list", "-api", server.URL, "-token", "guess"}, out); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected the API to refuse a request with the wrong token but received %v", err)
+	}
+
+	if err := RunCommand([]string{"mute"}, out); err == nil {
+		t.Errorf("Expected an error for an unknown action")
+	}
+
+}
+
+// TestAuthorized tests the bearer token check of the silence API.
+func TestAuthorized(t *testing.T) {
+
+	testSuite := map[string]struct {
+		token          string
+		header         string
+		expectedStatus int
+	}{
+		"A request with the token should be served": {
+			token:          "secret",
+			header:         "Bearer secret",
+			expectedStatus: http.StatusOK,
+		},
+		"A request without a token should be refused": {
+			token:          "secret",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"A request with another token should be refused": {
+			token:          "secret",
+			header:         "Bearer secrets",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"A request with the token in another scheme should be refused": {
+			token:          "secret",
+			header:         "Basic secret",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"An API without a token should refuse everything": {
+			header:         "Bearer ",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		req := httptest.NewRequest(http.MethodGet, apiPath, nil)
+		if testCase.header != "" {
+			req.Header.Set("Authorization", testCase.header)
+		}
+
+		response := httptest.NewRecorder()
+		NewHandler(NewRegistry(), testCase.token).ServeHTTP(response, req)
+
+		if response.Code != testCase.expectedStatus {
+			t.Errorf("Expected the status %v but received %v", testCase.expectedStatus, response.Code)
+		}
+	}
+
+	if err := Serve(context.Background(), ":0", "", NewRegistry()); err == nil {
+		t.Errorf("Expected Serve() to refuse to serve the API without a token")
+	}
+
+}
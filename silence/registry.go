@@ -0,0 +1,210 @@
+// Package silence keeps the silences that suppress notifications, the ones from the config and the ones created while Hubbub is
+// running through the HTTP API or the silence subcommand.
+package silence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// Registry holds the configured silences and the ones created at runtime. The configured silences are replaced each time the
+// config is loaded, the runtime silences are kept until they end or are deleted. It is shared by the watches and the API so it
+// is guarded by a mutex, a nil Registry matches nothing.
+type Registry struct {
+	mu         sync.Mutex
+	configured []models.Silence
+	runtime    []models.Silence
+	// changed is set when the runtime silences change and cleared by Snapshot
+	changed bool
+}
+
+// NewRegistry returns a Registry with no silences.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// SetConfigured replaces the silences from the config.
+func (r *Registry) SetConfigured(silences []models.Silence) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configured = silences
+}
+
+// Add validates a runtime silence and adds it, the silence starts straight away if it has no start time. The silence is returned
+// with the ID it was given.
+func (r *Registry) Add(s models.Silence) (models.Silence, error) {
+
+	if s.Start.IsZero() {
+		s.Start = time.Now()
+	}
+
+	id, err := newID()
+	if err != nil {
+		return s, err
+	}
+	s.ID = id
+
+	if err := s.Validate(); err != nil {
+		return s, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+	r.runtime = append(r.runtime, s)
+	r.changed = true
+
+	return s, nil
+}
+
+// Delete removes the runtime silence with the id, the configured silences can only be removed from the config.
+func (r *Registry) Delete(id string) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.runtime {
+		if s.ID == id {
+			r.runtime = append(r.runtime[:i], r.runtime[i+1:]...)
+			r.changed = true
+			return nil
+		}
+	}
+
+	for _, s := range r.configured {
+		if s.ID == id {
+			return fmt.Errorf("the silence %v is in the config, it can only be removed from the config", id)
+		}
+	}
+
+	return fmt.Errorf("there is no silence %v", id)
+}
+
+// List returns every silence that has not ended, ordered by the time they end.
+func (r *Registry) List() []models.Silence {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.prune(now)
+
+	silences := []models.Silence{}
+	for _, s := range append(append([]models.Silence{}, r.configured...), r.runtime...) {
+		if now.Before(s.End) {
+			silences = append(silences, s)
+		}
+	}
+
+	sort.Slice(silences, func(i, j int) bool { return silences[i].End.Before(silences[j].End) })
+	return silences
+}
+
+// Match returns the first silence that matches the failures in p.
+func (r *Registry) Match(p models.PodStatusInformation) (models.Silence, bool) {
+
+	if r == nil {
+		return models.Silence{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, silences := range [][]models.Silence{r.configured, r.runtime} {
+		for _, s := range silences {
+			if s.Matches(p, now) {
+				return s, true
+			}
+		}
+	}
+
+	return models.Silence{}, false
+}
+
+// Snapshot returns the runtime silences to be saved to the store and reports if they have changed since the last snapshot.
+func (r *Registry) Snapshot() ([]models.Silence, bool) {
+
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+	changed := r.changed
+	r.changed = false
+
+	return append([]models.Silence{}, r.runtime...), changed
+}
+
+// Touch marks the runtime silences as changed, so a snapshot that could not be saved is saved again.
+func (r *Registry) Touch() {
+
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.changed = true
+}
+
+// Restore adds the runtime silences saved to the store, the ones that have ended or are already known are skipped.
+func (r *Registry) Restore(silences []models.Silence) {
+
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	known := map[string]bool{}
+	for _, s := range r.runtime {
+		known[s.ID] = true
+	}
+
+	for _, s := range silences {
+		if !known[s.ID] && now.Before(s.End) {
+			r.runtime = append(r.runtime, s)
+		}
+	}
+}
+
+// prune drops the runtime silences that have ended.
+func (r *Registry) prune(now time.Time) {
+
+	active := r.runtime[:0]
+	for _, s := range r.runtime {
+		if now.Before(s.End) {
+			active = append(active, s)
+		} else {
+			r.changed = true
+		}
+	}
+	r.runtime = active
+}
+
+// newID returns a random id for a runtime silence.
+func newID() (string, error) {
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate an id for the silence : %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,64 @@
+package silence
+
+import (
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// TestRegistry tests that the Registry matches the configured and runtime silences, and that only the runtime silences can be deleted.
+func TestRegistry(t *testing.T) {
+
+	r := NewRegistry()
+	r.SetConfigured([]models.Silence{{ID: "config-1", Namespace: "payments", End: time.Now().Add(time.Hour)}})
+
+	runtime, err := r.Add(models.Silence{Workload: "worker", Comment: "queue migration", End: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Error adding the silence %v", err)
+	}
+
+	if _, err := r.Add(models.Silence{Namespace: "orders"}); err == nil {
+		t.Errorf("Expected an error adding a silence without an end")
+	}
+
+	testSuite := map[string]struct {
+		pod        models.PodStatusInformation
+		expectedID string
+	}{
+		"A pod in the configured namespace should match the configured silence": {
+			pod:        models.PodStatusInformation{Namespace: "payments", PodName: "api-1"},
+			expectedID: "config-1",
+		},
+		"A pod of the silenced workload should match the runtime silence": {
+			pod:        models.PodStatusInformation{Namespace: "orders", PodName: "worker-1", OwnerKind: "Deployment", OwnerName: "worker"},
+			expectedID: runtime.ID,
+		},
+		"Any other pod should not match": {
+			pod: models.PodStatusInformation{Namespace: "orders", PodName: "api-1"},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if matched, _ := r.Match(testCase.pod); matched.ID != testCase.expectedID {
+			t.Errorf("Expected the silence %v to match but received %v", testCase.expectedID, matched.ID)
+		}
+	}
+
+	if err := r.Delete("config-1"); err == nil {
+		t.Errorf("Expected an error deleting a configured silence")
+	}
+
+	if err := r.Delete(runtime.ID); err != nil || len(r.List()) != 1 {
+		t.Errorf("Expected the runtime silence to be deleted but received %v with %v silence(s) left", err, len(r.List()))
+	}
+
+	var nilRegistry *Registry
+	if _, ok := nilRegistry.Match(models.PodStatusInformation{}); ok {
+		t.Errorf("Expected a nil Registry to match nothing")
+	}
+
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapKey is the key in the ConfigMap that holds the state.
+const ConfigMapKey = "state.json"
+
+// configMapStore keeps the state as json in a ConfigMap, every replica can read it so a new leader picks up the state of the old one.
+type configMapStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+}
+
+// NewConfigMapStore returns a Store that keeps the state in the ConfigMap name in namespace, the ConfigMap is created on the first save.
+func NewConfigMapStore(kubeClient kubernetes.Interface, namespace, name string) Store {
+	return &configMapStore{kubeClient: kubeClient, namespace: namespace, name: name}
+}
+
+// Load reads the state from the ConfigMap, a missing ConfigMap or key is an empty state.
+func (c *configMapStore) Load() (*State, error) {
+
+	cm, err := c.kubeClient.CoreV1().ConfigMaps(c.namespace).Get(c.name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return &State{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to get the state ConfigMap %v/%v : %v", c.namespace, c.name, err)
+	}
+
+	state := &State{}
+	if content, ok := cm.Data[ConfigMapKey]; ok {
+		if err := json.Unmarshal([]byte(content), state); err != nil {
+			return nil, fmt.Errorf("unable to parse the state in ConfigMap %v/%v : %v", c.namespace, c.name, err)
+		}
+	}
+
+	return state, nil
+}
+
+// Save updates the ConfigMap with the state, creating it if it does not exist.
+func (c *configMapStore) Save(state *State) error {
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	configMaps := c.kubeClient.CoreV1().ConfigMaps(c.namespace)
+	cm, err := configMaps.Get(c.name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: meta_v1.ObjectMeta{Name: c.name, Namespace: c.namespace},
+			Data:       map[string]string{ConfigMapKey: string(content)},
+		}
+		if _, err := configMaps.Create(cm); err != nil {
+			return fmt.Errorf("unable to create the state ConfigMap %v/%v : %v", c.namespace, c.name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unable to get the state ConfigMap %v/%v : %v", c.namespace, c.name, err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ConfigMapKey] = string(content)
+
+	if _, err := configMaps.Update(cm); err != nil {
+		return fmt.Errorf("unable to update the state ConfigMap %v/%v : %v", c.namespace, c.name, err)
+	}
+
+	return nil
+}
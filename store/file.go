@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// fileStore keeps the state as json in a local file, such as one on a persistent volume.
+type fileStore struct {
+	path string
+}
+
+// NewFileStore returns a Store that keeps the state in the file at path.
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+// Load reads the state from the file, a missing file is an empty state.
+func (f *fileStore) Load() (*State, error) {
+
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read the state file %v : %v", f.path, err)
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("unable to parse the state file %v : %v", f.path, err)
+	}
+
+	return state, nil
+}
+
+// Save writes the state to a temporary file and renames it over the file, so a crash while saving does not leave half a state behind.
+func (f *fileStore) Save(state *State) error {
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	temp := f.path + ".tmp"
+	if err := ioutil.WriteFile(temp, content, 0600); err != nil {
+		return fmt.Errorf("unable to write the state file %v : %v", temp, err)
+	}
+
+	if err := os.Rename(temp, f.path); err != nil {
+		return fmt.Errorf("unable to replace the state file %v : %v", f.path, err)
+	}
+
+	return nil
+}
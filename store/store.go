@@ -0,0 +1,32 @@
+// Package store persists the state Hubbub keeps in memory, so that a restart or a new leader picks up where the last one stopped
+// instead of notifying every failure again.
+package store
+
+import (
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// Store loads and saves the State. Load returns an empty State if nothing has been saved yet.
+type Store interface {
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// State is the state that is persisted.
+type State struct {
+	Dedup     []DedupEntry      `json:"dedup"`
+	Incidents []models.Incident `json:"incidents,omitempty"`
+	// Silences are the silences created through the silence API, the silences in the config are not saved.
+	Silences []models.Silence `json:"silences,omitempty"`
+}
+
+// DedupEntry is a single key of the dedup cache, see the watcher package for how they are used.
+type DedupEntry struct {
+	Key        string    `json:"key"`
+	Notified   time.Time `json:"notified"`
+	Seen       time.Time `json:"seen"`
+	Occurrence string    `json:"occurrence,omitempty"`
+	Suppressed int       `json:"suppressed,omitempty"`
+}
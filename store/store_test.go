@@ -0,0 +1,75 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestStore tests that the state saved by each Store is loaded back, that nothing saved is an empty state and that a
+// second save replaces the first.
+func TestStore(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "hubbub")
+	if err != nil {
+		t.Fatalf("Error creating a temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testSuite := map[string]struct {
+		store Store
+	}{
+		"The file store should load what it saved": {
+			store: NewFileStore(filepath.Join(dir, "state.json")),
+		},
+		"The ConfigMap store should load what it saved": {
+			store: NewConfigMapStore(fake.NewSimpleClientset(), "monitoring", "hubbub-state"),
+		},
+	}
+
+	notified := time.Now().Round(time.Second).UTC()
+	exitCode := 137
+	states := []*State{
+		{Dedup: []DedupEntry{{Key: "hubbub/Deployment/api/api/Error", Notified: notified, Seen: notified, Occurrence: "api-1/1/10", Suppressed: 2}}},
+		{
+			Dedup: []DedupEntry{{Key: "hubbub/Deployment/api/api/OOMKilled", Notified: notified, Seen: notified}},
+			Incidents: []models.Incident{{Namespace: "hubbub", Workload: "Deployment/api", Causes: []string{"OOMKilled"}, Opened: notified, Notifications: 1,
+				Channel: "#oncall", Severity: "critical", Escalated: 2}},
+			Silences: []models.Silence{{ID: "abc", Comment: "maintenance", Namespace: "payments", Workload: "Deployment/*", Labels: "tier=db",
+				Reason: "OOMKilled", ExitCode: &exitCode, Start: notified, End: notified.Add(time.Hour)}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		state, err := testCase.store.Load()
+		if err != nil || len(state.Dedup) != 0 {
+			t.Errorf("Expected an empty state before anything is saved but received %v (%v)", state, err)
+		}
+
+		for _, expected := range states {
+
+			if err := testCase.store.Save(expected); err != nil {
+				t.Fatalf("Error saving the state %v", err)
+			}
+
+			state, err := testCase.store.Load()
+			if err != nil {
+				t.Fatalf("Error loading the state %v", err)
+			}
+
+			if !reflect.DeepEqual(state, expected) {
+				t.Errorf("Expected the state %+v but received %+v", expected, state)
+			}
+		}
+	}
+
+}
@@ -0,0 +1,88 @@
+package watcher
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// backfillSummary collects the pods that were already failed from every pod watch and sends them as a single notification
+// once all of the watches have listed their pods. It is shared by the pod watches so add is safe to call from each of them.
+type backfillSummary struct {
+	handler models.NotificationHandler
+
+	mu      sync.Mutex
+	pending int
+	pods    []models.PodStatusInformation
+}
+
+// newBackfillSummary returns a backfillSummary that waits for the initial list of 'watches' pod watches.
+func newBackfillSummary(handler models.NotificationHandler, watches int) *backfillSummary {
+	return &backfillSummary{handler: handler, pending: watches}
+}
+
+// add adds the failed pods found by one watch, the summary is sent by the last watch to call add. Nothing is sent if
+// none of the watches found a failed pod.
+func (b *backfillSummary) add(pods []models.PodStatusInformation) {
+
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pods = append(b.pods, pods...)
+	b.pending--
+	if b.pending != 0 || len(b.pods) == 0 {
+		return
+	}
+
+	if err := helpers.NewSummaryNotification(b.handler, b.pods); err != nil {
+		fmt.Println(err.Error()) // non termintating
+	}
+}
+
+// backfill checks the pods listed when the watch starts for ones that are already failed and not yet known. Each of them is seeded in the dedup
+// cache so a later change to the same failure does not generate a notification. In the report mode they are also added to the summary
+// and open an incident so their recovery is notified, in the seed mode nothing was sent so there is no incident to resolve.
+func (w *podWatcher) backfill(items []runtime.Object) {
+
+	failed := []models.PodStatusInformation{}
+	for _, item := range items {
+
+		pod, ok := item.(*v1.Pod)
+		if !ok {
+			continue
+		}
+
+		podInformation, ok := w.load(pod)
+		if !ok || !podInformation.Failed() {
+			continue
+		}
+
+		// known from an earlier run, after a config reload or from the store
+		if w.state.dedup.known(podInformation) {
+			continue
+		}
+
+		if w.state.silenced(podInformation) {
+			continue
+		}
+
+		if w.config.Backfill == models.BackfillReport {
+			w.state.notified(podInformation)
+		} else {
+			w.state.dedup.notified(podInformation)
+		}
+		failed = append(failed, podInformation)
+	}
+
+	helpers.DebugLog(w.config.Debug, "Backfill found "+strconv.Itoa(len(failed))+" failed pod(s)")
+	w.state.summary.add(failed)
+}
@@ -0,0 +1,89 @@
+package watcher
+
+import (
+	"testing"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestBackfill tests that the pods already failed when the watch starts are summarized or seeded, and that a later change to
+// one of those pods does not generate a notification. Only the failures that were reported open an incident.
+func TestBackfill(t *testing.T) {
+
+	running := testFailedPod("api-2", "10")
+	running.Status.Phase = v1.PodRunning
+	running.Status.ContainerStatuses[0].State = v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+
+	testSuite := map[string]struct {
+		mode                  string
+		pods                  []*v1.Pod
+		expectedSummaries     int
+		expectedNotifications int
+		expectOpen            bool
+	}{
+		"Without a backfill mode a failed pod should be reported when it changes": {
+			pods:                  []*v1.Pod{testFailedPod("api-1", "10"), running},
+			expectedNotifications: 1,
+			expectOpen:            true,
+		},
+		"The report mode should send one summary and not report the pod again": {
+			mode:              models.BackfillReport,
+			pods:              []*v1.Pod{testFailedPod("api-1", "10"), running},
+			expectedSummaries: 1,
+			expectOpen:        true,
+		},
+		"The seed mode should not send anything": {
+			mode: models.BackfillSeed,
+			pods: []*v1.Pod{testFailedPod("api-1", "10"), running},
+		},
+		"The report mode should not send a summary if no pods are failed": {
+			mode:                  models.BackfillReport,
+			pods:                  []*v1.Pod{running},
+			expectedNotifications: 1,
+			expectOpen:            true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5, Backfill: testCase.mode}
+		config.LoadEnvVars()
+
+		kubeClient := fake.NewSimpleClientset()
+		for _, pod := range testCase.pods {
+			kubeClient.Tracker().Add(pod)
+		}
+
+		state := testState(config, handler)
+		w := newPodWatch(kubeClient, "hubbub", config, handler, state)
+		items, err := w.listItems()
+		if err != nil {
+			t.Fatalf("Error listing the pods %v", err)
+		}
+		if w.backfill != nil {
+			w.backfill(items)
+		}
+
+		if handler.count != testCase.expectedSummaries {
+			t.Errorf("Expected %v summaries but received %v", testCase.expectedSummaries, handler.count)
+		}
+
+		// api-1 is modified again with the failure it had when the watch started
+		w.handle(watch.Modified, testFailedPod("api-1", "11"))
+
+		if notifications := handler.count - testCase.expectedSummaries; notifications != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, notifications)
+		}
+
+		if open := !state.incidents.empty(); open != testCase.expectOpen {
+			t.Errorf("Expected an open incident : %v, but received %v", testCase.expectOpen, open)
+		}
+	}
+
+}
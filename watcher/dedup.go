@@ -0,0 +1,212 @@
+package watcher
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/store"
+)
+
+// dedupCacheSize is the most keys the dedup cache holds. Once it is full the expired keys are dropped, followed by the keys
+// seen the longest time ago.
+const dedupCacheSize = 1000
+
+// dedupEntry is the state of a single dedup key.
+type dedupEntry struct {
+	// notified is when the last notification for the key was sent and seen when the key last failed
+	notified time.Time
+	seen     time.Time
+	// occurrence is the last occurrence counted, suppressed the occurrences since notified that were not sent
+	occurrence string
+	suppressed int
+}
+
+// dedupCache decides if a failure should generate a notification. Failures are keyed by PodStatusInformation.DedupKey(), once a key
+// is notified the same failure is suppressed for the window (the time in the config) and the suppressed repeats are counted so they
+// can be reported in the next notification. It is shared by every watch so it is guarded by a mutex.
+type dedupCache struct {
+	window time.Duration
+	size   int
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+	// changed is set when the entries change and cleared by snapshot
+	changed bool
+}
+
+// newDedupCache returns an empty dedupCache.
+func newDedupCache(window time.Duration, size int) *dedupCache {
+	return &dedupCache{window: window, size: size, entries: map[string]*dedupEntry{}}
+}
+
+// setWindow changes the window, the keys already notified keep the time they were notified at.
+func (d *dedupCache) setWindow(window time.Duration) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.window = window
+}
+
+// known reports if every failure in p has a key in the cache, whether or not its window is over.
+func (d *dedupCache) known(p models.PodStatusInformation) bool {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, f := range p.Failures {
+		if _, ok := d.entries[p.DedupKey(f)]; !ok {
+			return false
+		}
+	}
+
+	return len(p.Failures) > 0
+}
+
+// check reports if p should generate a notification, which is the case if one of its failures has not been notified within the
+// window. If it should the repeats suppressed since the last notification are added to p, if not the repeats in p are counted.
+// notified should be called once the notification is sent.
+func (d *dedupCache) check(p *models.PodStatusInformation) bool {
+
+	if !p.Failed() {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	notify := false
+	for _, f := range p.Failures {
+		if e, ok := d.entries[p.DedupKey(f)]; !ok || now.Sub(e.notified) >= d.window {
+			notify = true
+		}
+	}
+
+	for _, f := range p.Failures {
+
+		e, ok := d.entries[p.DedupKey(f)]
+		if !ok {
+			continue
+		}
+
+		if notify {
+			if e.suppressed > 0 {
+				p.Repeats += e.suppressed
+				if since := now.Sub(e.notified); since > p.RepeatWindow {
+					p.RepeatWindow = since
+				}
+			}
+			continue
+		}
+
+		if occurrence := p.Occurrence(f); occurrence != e.occurrence {
+			e.occurrence = occurrence
+			e.suppressed++
+			d.changed = true
+		}
+		e.seen = now
+	}
+
+	return notify
+}
+
+// notified records the failures in p as notified, starting a new window for each of them. It is also used to seed the cache with
+// failures that should not be notified, such as the pods that were already failed when Hubbub started.
+func (d *dedupCache) notified(p models.PodStatusInformation) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, f := range p.Failures {
+		d.entries[p.DedupKey(f)] = &dedupEntry{notified: now, seen: now, occurrence: p.Occurrence(f)}
+	}
+	d.changed = true
+
+	d.evict(now)
+}
+
+// evict drops keys until the cache is no larger than its size. The keys that are out of their window go first as their next
+// failure is notified anyway, then the keys that were seen the longest time ago.
+func (d *dedupCache) evict(now time.Time) {
+
+	if len(d.entries) <= d.size {
+		return
+	}
+
+	for key, e := range d.entries {
+		if now.Sub(e.notified) >= d.window {
+			delete(d.entries, key)
+		}
+	}
+
+	for len(d.entries) > d.size {
+
+		oldest := ""
+		for key, e := range d.entries {
+			if oldest == "" || e.seen.Before(d.entries[oldest].seen) {
+				oldest = key
+			}
+		}
+		delete(d.entries, oldest)
+	}
+}
+
+// forget drops every key that starts with prefix, such as the keys of a workload that has recovered.
+func (d *dedupCache) forget(prefix string) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key := range d.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(d.entries, key)
+			d.changed = true
+		}
+	}
+}
+
+// snapshot returns the entries to be saved to the store and reports if they have changed since the last snapshot.
+func (d *dedupCache) snapshot() ([]store.DedupEntry, bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries := make([]store.DedupEntry, 0, len(d.entries))
+	for key, e := range d.entries {
+		entries = append(entries, store.DedupEntry{Key: key, Notified: e.notified, Seen: e.seen, Occurrence: e.occurrence, Suppressed: e.suppressed})
+	}
+
+	changed := d.changed
+	d.changed = false
+
+	return entries, changed
+}
+
+// touch marks the entries as changed, so a snapshot that could not be saved is saved again.
+func (d *dedupCache) touch() {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.changed = true
+}
+
+// restore loads the entries saved to the store. Keys that are already out of their window are skipped.
+func (d *dedupCache) restore(entries []store.DedupEntry) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range entries {
+		if now.Sub(e.Notified) < d.window {
+			d.entries[e.Key] = &dedupEntry{notified: e.Notified, seen: e.Seen, occurrence: e.Occurrence, suppressed: e.Suppressed}
+		}
+	}
+
+	d.evict(now)
+}
@@ -0,0 +1,128 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// testFailure returns a failed pod owned by the Deployment owner, restarts identifies the occurrence of the failure.
+func testFailure(owner, pod, reason string, restarts int32) models.PodStatusInformation {
+
+	return models.PodStatusInformation{
+		Namespace: "hubbub",
+		PodName:   pod,
+		OwnerKind: "Deployment",
+		OwnerName: owner,
+		Failures: []models.ContainerFailure{{
+			ContainerName: "app",
+			Image:         "hubbub:1",
+			Reason:        reason,
+			RestartCount:  restarts,
+			FinishedAt:    time.Unix(int64(restarts)+1, 0),
+		}},
+	}
+}
+
+// dedupStep is a single failure passed to the dedupCache in TestDedupCache.
+type dedupStep struct {
+	pod models.PodStatusInformation
+	// expire moves every key out of its window before the step
+	expire          bool
+	expectNotify    bool
+	expectedRepeats int
+}
+
+// TestDedupCache tests that the dedupCache notifies each key once per window and counts the repeats it suppressed.
+func TestDedupCache(t *testing.T) {
+
+	testSuite := map[string]struct {
+		steps []dedupStep
+	}{
+		"Two flapping workloads should each be notified once": {
+			steps: []dedupStep{
+				{pod: testFailure("api", "api-1", "Error", 1), expectNotify: true},
+				{pod: testFailure("worker", "worker-1", "Error", 1), expectNotify: true},
+				{pod: testFailure("api", "api-1", "Error", 2)},
+				{pod: testFailure("worker", "worker-1", "Error", 2)},
+				{pod: testFailure("api", "api-1", "Error", 3)},
+			},
+		},
+		"Replicas of the same workload should share a key": {
+			steps: []dedupStep{
+				{pod: testFailure("api", "api-1", "OOMKilled", 1), expectNotify: true},
+				{pod: testFailure("api", "api-2", "OOMKilled", 1)},
+				{pod: testFailure("api", "api-3", "Error", 1), expectNotify: true},
+			},
+		},
+		"The suppressed repeats should be reported once the window is over": {
+			steps: []dedupStep{
+				{pod: testFailure("api", "api-1", "Error", 1), expectNotify: true},
+				{pod: testFailure("api", "api-1", "Error", 2)},
+				{pod: testFailure("api", "api-1", "Error", 3)},
+				{pod: testFailure("api", "api-1", "Error", 4), expire: true, expectNotify: true, expectedRepeats: 2},
+				{pod: testFailure("api", "api-1", "Error", 5)},
+			},
+		},
+		"A pod modified without failing again should not be counted as a repeat": {
+			steps: []dedupStep{
+				{pod: testFailure("api", "api-1", "Error", 1), expectNotify: true},
+				{pod: testFailure("api", "api-1", "Error", 2)},
+				{pod: testFailure("api", "api-1", "Error", 2)},
+				{pod: testFailure("api", "api-1", "Error", 3), expire: true, expectNotify: true, expectedRepeats: 1},
+			},
+		},
+		"A pod that has not failed should not be notified": {
+			steps: []dedupStep{
+				{pod: models.PodStatusInformation{Namespace: "hubbub", PodName: "api-1"}},
+			},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		d := newDedupCache(5*time.Minute, dedupCacheSize)
+		for i, step := range testCase.steps {
+
+			if step.expire {
+				for _, e := range d.entries {
+					e.notified = e.notified.Add(-d.window)
+				}
+			}
+
+			p := step.pod
+			notify := d.check(&p)
+			if notify {
+				d.notified(p)
+			}
+
+			if notify != step.expectNotify || p.Repeats != step.expectedRepeats {
+				t.Errorf("Step %v : expected notify %v with %v repeats but received %v with %v repeats", i, step.expectNotify, step.expectedRepeats, notify, p.Repeats)
+			}
+		}
+	}
+
+}
+
+// TestDedupCacheSize tests that the dedupCache drops the keys seen the longest time ago once it is full.
+func TestDedupCacheSize(t *testing.T) {
+
+	d := newDedupCache(5*time.Minute, 2)
+	for _, owner := range []string{"api", "worker", "web"} {
+		d.notified(testFailure(owner, owner+"-1", "Error", 1))
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(d.entries) != 2 {
+		t.Errorf("Expected the cache to hold 2 keys but it holds %v", len(d.entries))
+	}
+
+	api := testFailure("api", "api-1", "Error", 2)
+	if !d.check(&api) {
+		t.Errorf("Expected the key seen the longest time ago to have been dropped")
+	}
+
+}
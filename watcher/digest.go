@@ -0,0 +1,158 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// digestSeenLimit is the most occurrences the digest remembers to avoid counting a failure twice. Once it is full it is cleared, a
+// failure that is seen again after that is counted again.
+const digestSeenLimit = 5000
+
+// digestRecorder counts the failures seen over the period of the digest. A pod is modified many times for a single failure, so each
+// occurrence of a failure (see models.PodStatusInformation.Occurrence) is only counted once. It is shared by every watch so it is
+// guarded by a mutex. A nil digestRecorder records nothing, which is the case when the digest is disabled.
+type digestRecorder struct {
+	period  string
+	hour    int
+	top     int
+	handler models.NotificationHandler
+
+	mu     sync.Mutex
+	digest *models.Digest
+	// seen is the last occurrence counted for each container of a pod and cause
+	seen map[string]string
+}
+
+// newDigestRecorder returns the recorder for the digest in the config, it is nil if no digest period is set. The digest is sent with
+// the digest handler of the config when it has one, or with handler.
+func newDigestRecorder(config *models.Config, handler models.NotificationHandler, now time.Time) *digestRecorder {
+
+	if config.Digest.Period == "" {
+		return nil
+	}
+
+	if config.Digest.Handler != nil {
+		handler = config.Digest.Handler
+	}
+
+	return &digestRecorder{
+		period:  config.Digest.Period,
+		hour:    config.Digest.Hour,
+		top:     config.Digest.Top,
+		handler: handler,
+		digest:  models.NewDigest(now),
+		seen:    map[string]string{},
+	}
+}
+
+// configure changes the period, hour, top and handler of the recorder to those in the config, the failures counted so far are kept.
+func (r *digestRecorder) configure(config *models.Config, handler models.NotificationHandler) {
+
+	if config.Digest.Handler != nil {
+		handler = config.Digest.Handler
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.period, r.hour, r.top, r.handler = config.Digest.Period, config.Digest.Hour, config.Digest.Top, handler
+}
+
+// record counts the failures in p that have not been counted.
+func (r *digestRecorder) record(p models.PodStatusInformation) {
+
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.seen) > digestSeenLimit {
+		r.seen = map[string]string{}
+	}
+
+	failures := []models.ContainerFailure{}
+	for _, f := range p.Failures {
+		key, occurrence := p.Namespace+"/"+p.PodName+"/"+f.ContainerName+"/"+f.Cause(), p.Occurrence(f)
+		if r.seen[key] == occurrence {
+			continue
+		}
+		r.seen[key] = occurrence
+		failures = append(failures, f)
+	}
+
+	p.Failures = failures
+	r.digest.Add(p)
+}
+
+// take returns the digest ending at 'now' and starts the next one.
+func (r *digestRecorder) take(now time.Time) models.Digest {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digest := r.digest
+	digest.End = now
+	r.digest = models.NewDigest(now)
+
+	return *digest
+}
+
+// record counts the failures in p for the digest unless they match a silence.
+func (s *State) record(p models.PodStatusInformation) {
+
+	if s.digest == nil {
+		return
+	}
+
+	if _, silenced := s.silences.Match(p); silenced {
+		return
+	}
+
+	s.digest.record(p)
+}
+
+// sendDigests sends the digest at the end of every period until ctx is done, along with the incidents that are still open. The
+// time of a daily digest is in loc. It returns straight away if the digest is disabled.
+func (s *State) sendDigests(ctx context.Context, loc *time.Location) {
+
+	if s.digest == nil {
+		return
+	}
+
+	if loc == nil {
+		loc = time.Local
+	}
+
+	for {
+		next := models.NextDigest(time.Now().In(loc), s.digest.period, s.digest.hour)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			s.sendDigest(now.In(loc))
+		}
+	}
+}
+
+// sendDigest sends the digest ending at 'now', a digest that can not be sent is only logged.
+func (s *State) sendDigest(now time.Time) {
+
+	digest := s.digest.take(now)
+	digest.Unresolved = s.incidents.list()
+
+	helpers.DebugLog(s.debug, fmt.Sprintf("Sending the digest of %v failure(s) and %v unresolved incident(s)", digest.Failures, len(digest.Unresolved)))
+	if err := helpers.NewDigestNotification(s.digest.handler, digest, s.digest.top); err != nil {
+		fmt.Println(err.Error()) // non termintating
+	}
+}
@@ -0,0 +1,131 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestDigestRecord tests that each failure seen by the pod watch is counted once for the digest, including the repeats that are not
+// notified, and that the failures matching a silence are not counted.
+func TestDigestRecord(t *testing.T) {
+
+	restarted := testFailedPod("api-1", "14")
+	restarted.Status.ContainerStatuses[0].RestartCount = 1
+
+	testSuite := map[string]struct {
+		silence          models.Silence
+		events           []watch.Event
+		expectedFailures int
+	}{
+		"A pod that is modified without failing again should be counted once": {
+			events: []watch.Event{
+				{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+				{Type: watch.Modified, Object: testFailedPod("api-1", "13")},
+			},
+			expectedFailures: 1,
+		},
+		"A repeat that is not notified should be counted": {
+			events: []watch.Event{
+				{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+				{Type: watch.Modified, Object: restarted},
+			},
+			expectedFailures: 2,
+		},
+		"A silenced pod should not be counted": {
+			silence: models.Silence{ID: "maintenance", Namespace: "hubbub", End: time.Now().Add(time.Hour)},
+			events: []watch.Event{
+				{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+			},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Digest.Period = models.DigestHourly
+		config.LoadEnvVars()
+
+		silences := silence.NewRegistry()
+		if testCase.silence.ID != "" {
+			silences.SetConfigured([]models.Silence{testCase.silence})
+		}
+		state, _ := configuredState(nil, config, handler, silences)
+		w := newPodWatch(fake.NewSimpleClientset(), "hubbub", config, handler, state)
+
+		w.consume(context.Background(), testWatch(testCase.events))
+
+		digest := state.digest.take(time.Now())
+		if digest.Failures != testCase.expectedFailures {
+			t.Errorf("Expected %v failure(s) in the digest but received %v", testCase.expectedFailures, digest.Failures)
+		}
+
+		if testCase.expectedFailures > 0 && digest.Pods["hubbub/api-1"] != testCase.expectedFailures {
+			t.Errorf("Expected the failures to be counted for the pod but received %v", digest.Pods)
+		}
+	}
+
+}
+
+// TestSendDigest tests that the digest is sent through the digest handler when the config has one, and that the next digest starts
+// empty.
+func TestSendDigest(t *testing.T) {
+
+	testSuite := map[string]struct {
+		digestHandler  bool
+		expectedDigest int
+		expectedMain   int
+	}{
+		"The digest should be sent through the handler of the config": {
+			expectedMain: 1,
+		},
+		"The digest should be sent through the digest handler": {
+			digestHandler:  true,
+			expectedDigest: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler, digestHandler := &countingHandler{}, &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Digest.Period = models.DigestDaily
+		if testCase.digestHandler {
+			config.Digest.Handler = digestHandler
+		}
+		config.LoadEnvVars()
+
+		state := testState(config, handler)
+		state.record(models.PodStatusInformation{Namespace: "hubbub", PodName: "api-1",
+			Failures: []models.ContainerFailure{{ContainerName: "app", Reason: "Error", ExitCode: 1}}})
+
+		state.sendDigest(time.Now())
+
+		if handler.count != testCase.expectedMain || digestHandler.count != testCase.expectedDigest {
+			t.Errorf("Expected %v and %v notification(s) through the handler and the digest handler but received %v and %v", testCase.expectedMain,
+				testCase.expectedDigest, handler.count, digestHandler.count)
+		}
+
+		if next := state.digest.take(time.Now()); next.Failures != 0 {
+			t.Errorf("Expected the next digest to start empty but it has %v failure(s)", next.Failures)
+		}
+	}
+
+	// no digest is recorded or sent when it is disabled
+	config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+	config.LoadEnvVars()
+	if state := testState(config, &countingHandler{}); state.digest != nil {
+		t.Errorf("Expected no digest without a period")
+	}
+
+}
@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// escalationCheckInterval is how often the open incidents are checked for an escalation step that is due, a step is sent at most
+// this long after its delay.
+const escalationCheckInterval = 10 * time.Second
+
+// escalationQuietPeriod is how long after a workload was last seen failing its incident stops escalating. A Job or a bare pod is
+// never checked for available replicas, a pod that is deleted while it is failing would otherwise escalate until it is evicted.
+const escalationQuietPeriod = 15 * time.Minute
+
+// checkEscalations escalates the open incidents every interval until ctx is done, it returns straight away if there are no
+// escalation policies.
+func (s *State) checkEscalations(ctx context.Context, interval time.Duration) {
+
+	if len(s.escalations) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.escalate(now)
+		}
+	}
+}
+
+// escalate sends every step of the escalation policy of each open incident that is due at 'now' and has not been sent, in order.
+// A step that can not be sent is tried again on the next check, the steps after it wait for it. The incidents that are recovering
+// or have not been seen failing for the quiet period are not escalated, nor are the ones matching a silence, the steps that are
+// due are sent if they fail again or the silence ends.
+func (s *State) escalate(now time.Time) {
+
+	for _, incident := range s.incidents.list() {
+
+		policy, ok := s.escalationPolicy(incident)
+		if !ok {
+			continue
+		}
+
+		if !incident.Recovering.IsZero() || now.Sub(incident.Failing) > escalationQuietPeriod {
+			continue
+		}
+
+		for step := incident.Escalated + 1; step <= len(policy.Steps); step++ {
+
+			if now.Sub(incident.Opened) < policy.Steps[step-1].Delay() {
+				break
+			}
+
+			if matched, silenced := s.silences.Match(incident.Failure()); silenced {
+				helpers.DebugLog(s.debug, fmt.Sprintf("Not escalating the incident for %v, it matched the silence %v", incident.Key(), matched))
+				break
+			}
+
+			helpers.DebugLog(s.debug, fmt.Sprintf("Escalating the incident for %v, step %v of the policy %v", incident.Key(), step, policy.Name))
+			if err := helpers.NewEscalationNotification(incident, policy, step); err != nil {
+				fmt.Println(err.Error()) // non termintating
+				break
+			}
+
+			s.incidents.escalated(incident.Key(), step)
+		}
+	}
+}
+
+// resolveEscalation sends the resolved notification for an incident through the handlers of the escalation steps that were sent for
+// it, so a page is closed along with the incident. The notification is sent once for each handler.
+func (s *State) resolveEscalation(incident models.Incident) {
+
+	if incident.Escalated == 0 {
+		return
+	}
+
+	policy, ok := s.escalationPolicy(incident)
+	if !ok {
+		return
+	}
+
+	// the channel of the incident is where the failures were posted, the handler of the step posts to its own channel
+	incident.Channel = ""
+
+	sent := map[models.NotificationHandler]bool{}
+	for _, step := range policy.Steps[:min(incident.Escalated, len(policy.Steps))] {
+
+		if step.Handler == nil || sent[step.Handler] {
+			continue
+		}
+		sent[step.Handler] = true
+
+		if err := helpers.NewResolvedNotification(step.Handler, incident); err != nil {
+			fmt.Println(err.Error()) // non termintating
+		}
+	}
+}
+
+// escalationPolicy returns the first escalation policy that matches the incident.
+func (s *State) escalationPolicy(incident models.Incident) (models.EscalationPolicy, bool) {
+
+	for _, policy := range s.escalations {
+		if policy.Matches(incident) {
+			return policy, true
+		}
+	}
+
+	return models.EscalationPolicy{}, false
+}
+
+// min returns the smaller of a and b.
+func min(a, b int) int {
+
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,132 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
+)
+
+// TestEscalation tests that the steps of an escalation policy are sent once each when they are due, and that the handlers of the
+// steps that were sent receive the resolved notification. Unless the test case sets quiet the workload is seen failing when the
+// steps are due.
+func TestEscalation(t *testing.T) {
+
+	testSuite := map[string]struct {
+		severity string
+		elapsed  time.Duration
+		// quiet is the time since the workload was last seen failing
+		quiet             time.Duration
+		recovering        bool
+		silences          []models.Silence
+		expectedEscalated int
+	}{
+		"Nothing should be sent before the first step is due": {
+			severity: models.SeverityCritical,
+			elapsed:  5 * time.Minute,
+		},
+		"The first step should be sent once it is due": {
+			severity:          models.SeverityCritical,
+			elapsed:           10 * time.Minute,
+			expectedEscalated: 1,
+		},
+		"Every step that is due should be sent": {
+			severity:          models.SeverityCritical,
+			elapsed:           45 * time.Minute,
+			expectedEscalated: 2,
+		},
+		"An incident the policy does not match should not be escalated": {
+			severity: models.SeverityWarning,
+			elapsed:  45 * time.Minute,
+		},
+		"An incident matching a silence should not be escalated": {
+			severity: models.SeverityCritical,
+			elapsed:  45 * time.Minute,
+			silences: []models.Silence{{ID: "maintenance", Workload: "api", End: time.Now().Add(time.Hour)}},
+		},
+		"A silence for another reason should not stop the escalation": {
+			severity:          models.SeverityCritical,
+			elapsed:           45 * time.Minute,
+			silences:          []models.Silence{{ID: "maintenance", Reason: "Error", End: time.Now().Add(time.Hour)}},
+			expectedEscalated: 2,
+		},
+		"An incident that has not failed for the quiet period should not be escalated": {
+			severity: models.SeverityCritical,
+			elapsed:  45 * time.Minute,
+			quiet:    20 * time.Minute,
+		},
+		"An incident that is recovering should not be escalated": {
+			severity:   models.SeverityCritical,
+			elapsed:    45 * time.Minute,
+			recovering: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		steps := []*countingHandler{{}, {}}
+
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Escalations = []models.EscalationPolicy{{
+			Name:       "pager",
+			Severities: []string{models.SeverityCritical},
+			Steps:      []models.EscalationStep{{After: 600, Handler: steps[0]}, {After: 1800, Handler: steps[1]}},
+		}}
+		config.LoadEnvVars()
+
+		silences := silence.NewRegistry()
+		silences.SetConfigured(testCase.silences)
+		state, _ := configuredState(nil, config, handler, silences)
+		p := models.PodStatusInformation{Namespace: "hubbub", PodName: "api-1", OwnerKind: "Deployment", OwnerName: "api", Severity: testCase.severity,
+			Failures: []models.ContainerFailure{{ContainerName: "app", Reason: "OOMKilled", ExitCode: 137}}}
+		state.notified(p)
+
+		// the second check should not send the steps again
+		now := time.Now().Add(testCase.elapsed)
+		state.incidents.failing(p.IncidentKey(), now.Add(-testCase.quiet))
+		if testCase.recovering {
+			state.incidents.recovering(p.IncidentKey(), now)
+		}
+		state.escalate(now)
+		state.escalate(now.Add(time.Second))
+
+		for i, step := range steps {
+			if expected := boolToInt(i < testCase.expectedEscalated); step.count != expected {
+				t.Errorf("Expected %v notification(s) for step %v but received %v", expected, i+1, step.count)
+			}
+		}
+
+		if incidents := state.incidents.list(); incidents[0].Escalated != testCase.expectedEscalated {
+			t.Errorf("Expected %v step(s) to be recorded but received %v", testCase.expectedEscalated, incidents[0].Escalated)
+		}
+
+		// resolving the incident cancels the steps that are left and resolves the ones that were sent
+		state.resolve(p.IncidentKey())
+		state.escalate(now.Add(time.Hour))
+
+		for i, step := range steps {
+			if expected := 2 * boolToInt(i < testCase.expectedEscalated); step.count != expected {
+				t.Errorf("Expected %v notification(s) for step %v once resolved but received %v", expected, i+1, step.count)
+			}
+		}
+
+		if handler.count != 1 {
+			t.Errorf("Expected the resolved notification to be sent through the handler but received %v notification(s)", handler.count)
+		}
+	}
+
+}
+
+// boolToInt returns 1 if b is set.
+func boolToInt(b bool) int {
+
+	if b {
+		return 1
+	}
+
+	return 0
+}
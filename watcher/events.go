@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// eventFieldSelector limits the event watch to Warning events about pods.
+const eventFieldSelector = "type=Warning,involvedObject.kind=Pod"
+
+// eventWatcher holds the state used to decide if a Warning event in a single namespace should generate a notification.
+// lastSeen is the time of the newest event checked, events listed after the resourceVersion expires are only checked if they are newer.
+type eventWatcher struct {
+	config   *models.Config
+	handler  models.NotificationHandler
+	state    *State
+	lastSeen time.Time
+	owners   *ownerResolver
+}
+
+// newEventWatch returns the resumableWatch for the Warning events in a namespace. Unlike pods both Added and Modified events
+// are checked, Kubernetes modifies an event when it is repeated and bumps its count.
+func newEventWatch(kubeClient kubernetes.Interface, namespace string, config *models.Config, handler models.NotificationHandler, state *State) *resumableWatch {
+
+	ew := &eventWatcher{config: config, handler: handler, state: state, lastSeen: time.Now(), owners: newOwnerResolver(kubeClient, config.Debug)}
+	events := kubeClient.CoreV1().Events(namespace)
+
+	return &resumableWatch{
+		kind:      "events",
+		namespace: namespace,
+		debug:     config.Debug,
+		options:   meta_v1.ListOptions{FieldSelector: eventFieldSelector},
+		list: func(options meta_v1.ListOptions) (string, []runtime.Object, error) {
+			list, err := events.List(options)
+			if err != nil {
+				return "", nil, err
+			}
+			items := make([]runtime.Object, len(list.Items))
+			for i := range list.Items {
+				items[i] = &list.Items[i]
+			}
+			return list.ResourceVersion, items, nil
+		},
+		watch: events.Watch,
+		handle: func(eventType watch.EventType, obj runtime.Object) {
+			if event, ok := obj.(*v1.Event); ok {
+				ew.checkEvent(event)
+			}
+		},
+		resync: func(obj runtime.Object) {
+			if event, ok := obj.(*v1.Event); ok && models.EventTime(event).After(ew.lastSeen) {
+				ew.checkEvent(event)
+			}
+		},
+	}
+}
+
+// checkEvent generates a notification for a Warning event if its reason is one we alert on and it is not a repeat, when grouping is
+// enabled the event is added to its group instead.
+func (w *eventWatcher) checkEvent(event *v1.Event) {
+
+	if t := models.EventTime(event); t.After(w.lastSeen) {
+		w.lastSeen = t
+	}
+
+	if event.Type != v1.EventTypeWarning || !w.config.EventReasonMatch(event.Reason) {
+		return
+	}
+
+	// ignore namespaces that are excluded or not included when watching the whole cluster
+	if !w.config.NamespaceMatch(event.InvolvedObject.Namespace) {
+		return
+	}
+
+	// ignore self
+	if w.config.Self != "" && strings.Contains(strings.ToLower(event.InvolvedObject.Name), strings.ToLower(w.config.Self)) {
+		return
+	}
+
+	helpers.DebugLog(w.config.Debug, "New warning event detected : "+event.Reason+" for "+event.InvolvedObject.Name+"\nMessage :"+event.Message)
+
+	eventInformation := models.PodStatusInformation{}
+	eventInformation.LoadEvent(event)
+	eventInformation.ConvertTime(w.config.TimeLocation)
+
+	// the event watch can not be filtered on the labels of the pod, a pod that could not be read is out of scope as well
+	owner := w.owners.resolveName(eventInformation.Namespace, eventInformation.PodName)
+	if !w.config.LabelMatch(owner.labels) {
+		helpers.DebugLog(w.config.Debug, "Skipping event : "+event.Reason+" for "+event.InvolvedObject.Name+" as the pod does not match the label selector")
+		return
+	}
+
+	if models.Ignored(owner.annotations) {
+		helpers.DebugLog(w.config.Debug, "Skipping event : "+event.Reason+" for "+event.InvolvedObject.Name+" as the pod is annotated with "+models.AnnotationIgnore)
+		return
+	}
+
+	eventInformation.OwnerKind, eventInformation.OwnerName = owner.kind, owner.name
+	eventInformation.Labels = owner.labels
+	eventInformation.LoadAnnotations(owner.annotations)
+	eventInformation.Classify(w.config)
+
+	if !w.config.SeverityNotified(eventInformation.Severity) {
+		helpers.DebugLog(w.config.Debug, "Skipping event : "+event.Reason+" for "+event.InvolvedObject.Name+" as its severity '"+eventInformation.Severity+"' is below the minimum severity")
+		return
+	}
+
+	w.state.record(eventInformation)
+	w.state.incidents.failing(eventInformation.IncidentKey(), time.Now())
+
+	if ok := w.state.dedup.check(&eventInformation); ok {
+
+		if w.state.silenced(eventInformation) {
+			return
+		}
+
+		helpers.DebugLog(w.config.Debug, "Event : "+event.Reason+" for "+event.InvolvedObject.Name+", is new. Generating a notification.")
+
+		if w.state.group != nil {
+			w.state.group.add(eventInformation)
+			return
+		}
+
+		if err := helpers.NewNotification(w.handler, eventInformation); err != nil {
+			fmt.Println(err.Error()) // non termintating
+		} else {
+			w.state.notified(eventInformation)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// failureGroup is the failures buffered for a single group key, pods holds the latest failure of each pod in the order the
+// pods first failed. counts is the number of times each pod failed, a pod is modified many times for a single failure so only
+// the occurrences that are not in seen are counted (see models.PodStatusInformation.Occurrence).
+type failureGroup struct {
+	pods   []models.PodStatusInformation
+	counts map[string]int
+	seen   map[string]bool
+	first  time.Time
+	timer  *time.Timer
+}
+
+// grouper buffers the failures that share a PodStatusInformation.GroupKey() so a bad rollout sends one notification instead of
+// one for each replica. A group is sent window after its last failure, but no later than maxWait after its first failure. The
+// pods that are sent are passed to notified. It is shared by every pod watch so it is guarded by a mutex.
+type grouper struct {
+	window   time.Duration
+	maxWait  time.Duration
+	handler  models.NotificationHandler
+	notified func(models.PodStatusInformation)
+	debug    bool
+
+	mu     sync.Mutex
+	groups map[string]*failureGroup
+}
+
+// newGrouper returns an empty grouper that sends the groups with handler, notified is called for each pod once its group is sent.
+func newGrouper(window, maxWait time.Duration, handler models.NotificationHandler, notified func(models.PodStatusInformation), debug bool) *grouper {
+	return &grouper{window: window, maxWait: maxWait, handler: handler, notified: notified, debug: debug, groups: map[string]*failureGroup{}}
+}
+
+// add buffers the failure in p, opening a group for its key if there is none. A pod that fails again while its group is open
+// replaces its earlier failure and is counted again, a change to the pod that is not a new failure is not counted.
+func (g *grouper) add(p models.PodStatusInformation) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := p.GroupKey()
+	now := time.Now()
+
+	group, ok := g.groups[key]
+	if !ok {
+		group = &failureGroup{counts: map[string]int{}, seen: map[string]bool{}, first: now}
+		group.timer = time.AfterFunc(g.window, func() { g.send(key, group) })
+		g.groups[key] = group
+		helpers.DebugLog(g.debug, "Opened the group : "+key)
+	} else {
+		// the group is sent when the window after this failure is over, unless that is past the max wait
+		wait := g.window
+		if remaining := group.first.Add(g.maxWait).Sub(now); remaining < wait {
+			wait = remaining
+		}
+		group.timer.Reset(wait)
+	}
+
+	if _, seen := group.counts[p.PodName]; !seen {
+		group.pods = append(group.pods, p)
+	} else {
+		for i := range group.pods {
+			if group.pods[i].PodName == p.PodName {
+				group.pods[i] = p
+			}
+		}
+	}
+	for _, f := range p.Failures {
+		if occurrence := f.ContainerName + "/" + p.Occurrence(f); !group.seen[occurrence] {
+			group.seen[occurrence] = true
+			group.counts[p.PodName]++
+		}
+	}
+}
+
+// send sends the group if it is still open, a timer that fires after the group was sent by flush does nothing.
+func (g *grouper) send(key string, group *failureGroup) {
+
+	g.mu.Lock()
+	if g.groups[key] != group {
+		g.mu.Unlock()
+		return
+	}
+	delete(g.groups, key)
+	g.mu.Unlock()
+
+	g.notify(group)
+}
+
+// flush sends every open group straight away, it is called once the watches have stopped so nothing buffered is lost.
+func (g *grouper) flush() {
+
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	groups := g.groups
+	g.groups = map[string]*failureGroup{}
+	g.mu.Unlock()
+
+	for _, group := range groups {
+		group.timer.Stop()
+		g.notify(group)
+	}
+}
+
+// notify sends a group, a group with a single pod is sent as a regular notification. Once it is sent each pod is passed to notified.
+func (g *grouper) notify(group *failureGroup) {
+
+	var err error
+	if len(group.pods) == 1 {
+		err = helpers.NewNotification(g.handler, group.pods[0])
+	} else {
+		err = helpers.NewGroupNotification(g.handler, models.FailureGroup{Pods: group.pods, Counts: group.counts})
+	}
+
+	if err != nil {
+		fmt.Println(err.Error()) // non termintating
+		return
+	}
+
+	for _, p := range group.pods {
+		g.notified(p)
+	}
+}
@@ -0,0 +1,165 @@
+package watcher
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+)
+
+// lockedHandler is a NotificationHandler that counts the notifications it receives, the groups are sent from their timers so
+// the count is guarded by a mutex.
+type lockedHandler struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (l *lockedHandler) Init(config *models.Config) error { return nil }
+
+func (l *lockedHandler) Notify(details models.NotificationDetails) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+	return nil
+}
+
+func (l *lockedHandler) sent() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// TestGrouper tests that the grouper sends the failures that share a group key as a single notification, and that a group
+// that keeps failing is sent once the max wait is over.
+func TestGrouper(t *testing.T) {
+
+	testSuite := map[string]struct {
+		pods    []models.PodStatusInformation
+		window  time.Duration
+		maxWait time.Duration
+		// interval is the time between each failure, check is the time after the last failure the notifications are counted
+		interval     time.Duration
+		check        time.Duration
+		expectedSent int
+	}{
+		"The replicas of a workload failing the same way should be sent once": {
+			pods: []models.PodStatusInformation{
+				testFailure("api", "api-1", "Error", 1),
+				testFailure("api", "api-2", "Error", 1),
+				testFailure("api", "api-3", "Error", 1),
+				testFailure("api", "api-1", "Error", 2),
+			},
+			window:       50 * time.Millisecond,
+			maxWait:      time.Second,
+			check:        200 * time.Millisecond,
+			expectedSent: 1,
+		},
+		"Different workloads and reasons should be sent separately": {
+			pods: []models.PodStatusInformation{
+				testFailure("api", "api-1", "Error", 1),
+				testFailure("api", "api-2", "OOMKilled", 1),
+				testFailure("worker", "worker-1", "Error", 1),
+			},
+			window:       50 * time.Millisecond,
+			maxWait:      time.Second,
+			check:        200 * time.Millisecond,
+			expectedSent: 3,
+		},
+		"A group should not be sent before its window is over": {
+			pods: []models.PodStatusInformation{
+				testFailure("api", "api-1", "Error", 1),
+				testFailure("api", "api-2", "Error", 1),
+			},
+			window:   time.Second,
+			maxWait:  time.Second,
+			interval: 10 * time.Millisecond,
+			check:    10 * time.Millisecond,
+		},
+		"A group that keeps failing should be sent once the max wait is over": {
+			pods: []models.PodStatusInformation{
+				testFailure("api", "api-1", "Error", 1),
+				testFailure("api", "api-2", "Error", 1),
+				testFailure("api", "api-3", "Error", 1),
+				testFailure("api", "api-4", "Error", 1),
+				testFailure("api", "api-5", "Error", 1),
+			},
+			window:       200 * time.Millisecond,
+			maxWait:      300 * time.Millisecond,
+			interval:     120 * time.Millisecond,
+			expectedSent: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &lockedHandler{}
+		g := newGrouper(testCase.window, testCase.maxWait, handler, newDedupCache(5*time.Minute, dedupCacheSize).notified, false)
+
+		for _, p := range testCase.pods {
+			g.add(p)
+			time.Sleep(testCase.interval)
+		}
+		time.Sleep(testCase.check)
+
+		if sent := handler.sent(); sent != testCase.expectedSent {
+			t.Errorf("Expected %v notification(s) but received %v", testCase.expectedSent, sent)
+		}
+
+		g.flush()
+	}
+
+}
+
+// TestGrouperFlush tests that flush sends the open groups straight away and passes their pods to notified.
+func TestGrouperFlush(t *testing.T) {
+
+	handler := &lockedHandler{}
+	dedup := newDedupCache(5*time.Minute, dedupCacheSize)
+	g := newGrouper(time.Minute, time.Minute, handler, dedup.notified, false)
+
+	g.add(testFailure("api", "api-1", "Error", 1))
+	g.add(testFailure("api", "api-2", "Error", 1))
+	g.add(testFailure("worker", "worker-1", "Error", 1))
+	g.flush()
+
+	if sent := handler.sent(); sent != 2 {
+		t.Errorf("Expected 2 notifications once the groups are flushed but received %v", sent)
+	}
+
+	p := testFailure("api", "api-3", "Error", 2)
+	if dedup.check(&p) {
+		t.Errorf("Expected the flushed failures to be recorded in the dedup cache")
+	}
+
+}
+
+// TestGrouperCounts tests that a pod is only counted again when it fails again, not for every change to the pod while its
+// group is open.
+func TestGrouperCounts(t *testing.T) {
+
+	g := newGrouper(time.Minute, time.Minute, &lockedHandler{}, newDedupCache(5*time.Minute, dedupCacheSize).notified, false)
+	defer g.flush()
+
+	// api-1 is modified three times for its first failure and then restarts, api-2 fails once
+	for _, p := range []models.PodStatusInformation{
+		testFailure("api", "api-1", "Error", 1),
+		testFailure("api", "api-1", "Error", 1),
+		testFailure("api", "api-2", "Error", 1),
+		testFailure("api", "api-1", "Error", 1),
+		testFailure("api", "api-1", "Error", 2),
+	} {
+		g.add(p)
+	}
+
+	g.mu.Lock()
+	counts := g.groups[testFailure("api", "api-1", "Error", 1).GroupKey()].counts
+	g.mu.Unlock()
+
+	if counts["api-1"] != 2 || counts["api-2"] != 1 {
+		t.Errorf("Expected api-1 to be counted twice and api-2 once but received %v", counts)
+	}
+
+}
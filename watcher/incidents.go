@@ -0,0 +1,305 @@
+package watcher
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// incidentCheckInterval is how often the workloads with an open incident are checked for recovery. A pod that becomes Ready
+// is checked straight away, the interval catches the workloads whose status is updated after their last pod became Ready and
+// resolves the workloads that have stayed healthy for the recovery period.
+const incidentCheckInterval = 30 * time.Second
+
+// recoveryPeriod is how long a workload must stay healthy before its incident is resolved. A pod in CrashLoopBackOff is Ready for
+// a while after each restart, the period stops every crash from being resolved and notified again. It is a var for the tests.
+var recoveryPeriod = 5 * time.Minute
+
+// incidentLimit is the most incidents that are tracked, once it is reached the incident opened the longest time ago is dropped.
+// A bare pod that is deleted while it is failing never recovers, the limit stops those from piling up.
+const incidentLimit = 1000
+
+// incidentTracker tracks the workloads that have been notified and not yet recovered, keyed by PodStatusInformation.IncidentKey().
+// It is shared by every watch so it is guarded by a mutex.
+type incidentTracker struct {
+	size int
+
+	mu        sync.Mutex
+	incidents map[string]*models.Incident
+	// changed is set when the incidents change and cleared by snapshot
+	changed bool
+}
+
+// newIncidentTracker returns an incidentTracker with no open incidents.
+func newIncidentTracker(size int) *incidentTracker {
+	return &incidentTracker{size: size, incidents: map[string]*models.Incident{}}
+}
+
+// open records a notification for p, opening an incident for its workload if there is none.
+func (t *incidentTracker) open(p models.PodStatusInformation) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := p.IncidentKey()
+	incident, ok := t.incidents[key]
+	if !ok {
+		incident = &models.Incident{Namespace: p.Namespace, Workload: p.Workload(), Opened: time.Now()}
+		t.incidents[key] = incident
+	}
+	incident.Failing = time.Now()
+	if p.Labels != nil {
+		incident.Labels = p.Labels
+	}
+
+	for _, f := range p.Failures {
+		if !containsString(incident.Causes, f.Cause()) {
+			incident.Causes = append(incident.Causes, f.Cause())
+		}
+	}
+	incident.Notifications++
+	incident.Channel, incident.Severity = p.Channel, p.Severity
+	t.changed = true
+
+	t.evict()
+}
+
+// isOpen reports if an incident is open for the key.
+func (t *incidentTracker) isOpen(key string) bool {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.incidents[key]
+	return ok
+}
+
+// empty reports if there are no open incidents, it lets the pod watches skip the recovery check for every pod change.
+func (t *incidentTracker) empty() bool {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.incidents) == 0
+}
+
+// recovering records that the workload of the incident with the key is healthy at 'now' and returns the time it has been healthy
+// since. ok is false if the incident is not open.
+func (t *incidentTracker) recovering(key string, now time.Time) (since time.Time, ok bool) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incident, ok := t.incidents[key]
+	if !ok {
+		return since, false
+	}
+
+	if incident.Recovering.IsZero() {
+		incident.Recovering = now
+		t.changed = true
+	}
+
+	return incident.Recovering, true
+}
+
+// failing records that the workload of the incident with the key is failing, or is not healthy, at 'now' so its recovery starts over.
+func (t *incidentTracker) failing(key string, now time.Time) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if incident, ok := t.incidents[key]; ok {
+		incident.Recovering = time.Time{}
+		incident.Failing = now
+		t.changed = true
+	}
+}
+
+// resolve closes the incident for the key and returns it, nil is returned if it is not open.
+func (t *incidentTracker) resolve(key string) *models.Incident {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incident, ok := t.incidents[key]
+	if !ok {
+		return nil
+	}
+
+	delete(t.incidents, key)
+	t.changed = true
+
+	// the workload recovered when it became healthy, not at the end of the recovery period
+	incident.Resolved = incident.Recovering
+	if incident.Resolved.IsZero() {
+		incident.Resolved = time.Now()
+	}
+	return incident
+}
+
+// escalated records that the steps of the escalation policy up to step have been sent for the incident with the key.
+func (t *incidentTracker) escalated(key string, step int) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if incident, ok := t.incidents[key]; ok && incident.Escalated < step {
+		incident.Escalated = step
+		t.changed = true
+	}
+}
+
+// list returns a copy of the open incidents, the oldest first.
+func (t *incidentTracker) list() []models.Incident {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incidents := make([]models.Incident, 0, len(t.incidents))
+	for _, incident := range t.incidents {
+		incidents = append(incidents, *incident)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].Opened.Before(incidents[j].Opened) })
+	return incidents
+}
+
+// evict drops the incidents opened the longest time ago until there are no more than size of them.
+func (t *incidentTracker) evict() {
+
+	for len(t.incidents) > t.size {
+
+		oldest := ""
+		for key, incident := range t.incidents {
+			if oldest == "" || incident.Opened.Before(t.incidents[oldest].Opened) {
+				oldest = key
+			}
+		}
+		delete(t.incidents, oldest)
+	}
+}
+
+// snapshot returns the incidents to be saved to the store and reports if they have changed since the last snapshot.
+func (t *incidentTracker) snapshot() ([]models.Incident, bool) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	incidents := make([]models.Incident, 0, len(t.incidents))
+	for _, incident := range t.incidents {
+		incidents = append(incidents, *incident)
+	}
+
+	changed := t.changed
+	t.changed = false
+
+	return incidents, changed
+}
+
+// touch marks the incidents as changed, so a snapshot that could not be saved is saved again.
+func (t *incidentTracker) touch() {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.changed = true
+}
+
+// restore loads the incidents saved to the store.
+func (t *incidentTracker) restore(incidents []models.Incident) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range incidents {
+		incident := incidents[i]
+		t.incidents[incident.Key()] = &incident
+	}
+
+	t.evict()
+}
+
+// podRecovered reports if the pod is Ready, or has completed for the pods of a Job.
+func podRecovered(pod *v1.Pod) bool {
+
+	if pod.Status.Phase == v1.PodSucceeded {
+		return true
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// replicasAvailable reports if every replica of the workload is available. ok is false for the workloads that do not have replicas,
+// such as a bare pod or a Job, and for a workload that can not be read.
+func replicasAvailable(kubeClient kubernetes.Interface, namespace, workload string) (available, ok bool) {
+
+	parts := strings.SplitN(workload, "/", 2)
+	if len(parts) != 2 {
+		return false, false
+	}
+	kind, name := parts[0], parts[1]
+
+	switch kind {
+	case "Deployment":
+		d, err := kubeClient.AppsV1().Deployments(namespace).Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, false
+		}
+		return d.Status.AvailableReplicas >= replicas(d.Spec.Replicas) && d.Status.UnavailableReplicas == 0, true
+	case "StatefulSet":
+		ss, err := kubeClient.AppsV1().StatefulSets(namespace).Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, false
+		}
+		return ss.Status.ReadyReplicas >= replicas(ss.Spec.Replicas), true
+	case "DaemonSet":
+		ds, err := kubeClient.AppsV1().DaemonSets(namespace).Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, false
+		}
+		return ds.Status.NumberAvailable >= ds.Status.DesiredNumberScheduled && ds.Status.NumberUnavailable == 0, true
+	case "ReplicaSet":
+		rs, err := kubeClient.AppsV1().ReplicaSets(namespace).Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return false, false
+		}
+		return rs.Status.AvailableReplicas >= replicas(rs.Spec.Replicas), true
+	}
+
+	return false, false
+}
+
+// replicas returns the number of replicas in a spec, which defaults to 1 when it is not set.
+func replicas(spec *int32) int32 {
+
+	if spec == nil {
+		return 1
+	}
+
+	return *spec
+}
+
+// containsString reports if s is in list.
+func containsString(list []string, s string) bool {
+
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
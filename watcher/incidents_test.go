@@ -0,0 +1,233 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testOwnedPod returns pod with the metadata of testOwned(), the pod is failed if failed is set and Ready if not.
+func testOwnedPod(name, kind, ownerName string, failed bool) *v1.Pod {
+
+	pod := testFailedPod(name, "1")
+	pod.ObjectMeta = testOwned(name, kind, ownerName)
+
+	if !failed {
+		pod.Status = v1.PodStatus{
+			Phase:             v1.PodRunning,
+			Conditions:        []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+			ContainerStatuses: []v1.ContainerStatus{{Name: "app", Image: "hubbub:1", Ready: true, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}},
+		}
+	}
+
+	return pod
+}
+
+// testStatefulSet returns the StatefulSet redis with 2 replicas, ready of which are ready.
+func testStatefulSet(ready int32) *apps_v1.StatefulSet {
+
+	replicas := int32(2)
+	return &apps_v1.StatefulSet{
+		ObjectMeta: testOwned("redis", "", ""),
+		Spec:       apps_v1.StatefulSetSpec{Replicas: &replicas},
+		Status:     apps_v1.StatefulSetStatus{ReadyReplicas: ready},
+	}
+}
+
+// TestIncidentRecovery tests that a workload that was notified is resolved once its pod is Ready and every replica of the
+// workload is available, and that it is notified again if it fails after recovering. The recovery period is 0 unless the test
+// case sets one.
+func TestIncidentRecovery(t *testing.T) {
+
+	defer func(d time.Duration) { recoveryPeriod = d }(recoveryPeriod)
+
+	testSuite := map[string]struct {
+		objects               []runtime.Object
+		pods                  []*v1.Pod
+		recoveryPeriod        time.Duration
+		expectedNotifications int
+		expectOpen            bool
+	}{
+		"A bare pod that becomes Ready should be resolved": {
+			pods:                  []*v1.Pod{testOwnedPod("debug", "", "", true), testOwnedPod("debug", "", "", false)},
+			expectedNotifications: 2,
+		},
+		"A StatefulSet with a replica that is not ready should stay open": {
+			objects:               []runtime.Object{testStatefulSet(1)},
+			pods:                  []*v1.Pod{testOwnedPod("redis-0", "StatefulSet", "redis", true), testOwnedPod("redis-0", "StatefulSet", "redis", false)},
+			expectedNotifications: 1,
+			expectOpen:            true,
+		},
+		"A StatefulSet with every replica ready should be resolved": {
+			objects:               []runtime.Object{testStatefulSet(2)},
+			pods:                  []*v1.Pod{testOwnedPod("redis-0", "StatefulSet", "redis", true), testOwnedPod("redis-0", "StatefulSet", "redis", false)},
+			expectedNotifications: 2,
+		},
+		"A Ready pod of another workload should not resolve the incident": {
+			pods:                  []*v1.Pod{testOwnedPod("debug", "", "", true), testOwnedPod("shell", "", "", false)},
+			expectedNotifications: 1,
+			expectOpen:            true,
+		},
+		"A failure after the recovery should be notified again": {
+			pods: []*v1.Pod{
+				testOwnedPod("debug", "", "", true),
+				testOwnedPod("debug", "", "", false),
+				testOwnedPod("debug", "", "", true),
+			},
+			expectedNotifications: 3,
+			expectOpen:            true,
+		},
+		"A pod that is Ready between crashes should not be resolved during the recovery period": {
+			pods: []*v1.Pod{
+				testOwnedPod("debug", "", "", true),
+				testOwnedPod("debug", "", "", false),
+				testOwnedPod("debug", "", "", true),
+				testOwnedPod("debug", "", "", false),
+			},
+			recoveryPeriod:        time.Hour,
+			expectedNotifications: 1,
+			expectOpen:            true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		recoveryPeriod = testCase.recoveryPeriod
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.LoadEnvVars()
+
+		kubeClient := fake.NewSimpleClientset(testCase.objects...)
+		state, _ := configuredState(kubeClient, config, handler, nil)
+		w := newPodWatch(kubeClient, "hubbub", config, handler, state)
+
+		events := []watch.Event{}
+		for _, pod := range testCase.pods {
+			events = append(events, watch.Event{Type: watch.Modified, Object: pod})
+		}
+		w.consume(context.Background(), testWatch(events))
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+
+		if open := !state.incidents.empty(); open != testCase.expectOpen {
+			t.Errorf("Expected an open incident : %v, but received %v", testCase.expectOpen, open)
+		}
+	}
+
+}
+
+// TestIncidentRecoveryPeriod tests that an incident is only resolved once its workload has stayed healthy for the recovery
+// period, that a failure during the period starts it over and that the dedup keys are kept until it is resolved.
+func TestIncidentRecoveryPeriod(t *testing.T) {
+
+	defer func(d time.Duration) { recoveryPeriod = d }(recoveryPeriod)
+	recoveryPeriod = time.Minute
+
+	handler := &countingHandler{}
+	config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+	config.LoadEnvVars()
+	state := testState(config, handler)
+
+	p := testFailure("api", "api-1", "Error", 1)
+	key := p.IncidentKey()
+	state.dedup.check(&p)
+	state.notified(p)
+
+	now := time.Now()
+	state.recovered(key, now)
+	state.recovered(key, now.Add(30*time.Second))
+	if !state.incidents.isOpen(key) || !state.dedup.known(p) {
+		t.Fatalf("Expected the incident and its dedup keys to be kept during the recovery period")
+	}
+
+	// a failure during the period starts the recovery over
+	state.incidents.failing(key, now.Add(40*time.Second))
+	state.recovered(key, now.Add(45*time.Second))
+	state.recovered(key, now.Add(90*time.Second))
+	if !state.incidents.isOpen(key) {
+		t.Fatalf("Expected a failure during the recovery period to keep the incident open")
+	}
+
+	state.recovered(key, now.Add(105*time.Second))
+	if state.incidents.isOpen(key) || state.dedup.known(p) {
+		t.Errorf("Expected the incident and its dedup keys to be dropped after the recovery period")
+	}
+
+	if handler.count != 1 {
+		t.Errorf("Expected the resolved notification to be sent but received %v notification(s)", handler.count)
+	}
+
+}
+
+// TestReplicasAvailable tests the check of the workloads with replicas used to resolve their incidents.
+func TestReplicasAvailable(t *testing.T) {
+
+	replicas := int32(3)
+
+	testSuite := map[string]struct {
+		objects           []runtime.Object
+		workload          string
+		expectedAvailable bool
+		expectedOk        bool
+	}{
+		"A Deployment with every replica available should be available": {
+			objects: []runtime.Object{&apps_v1.Deployment{
+				ObjectMeta: testOwned("api", "", ""),
+				Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status:     apps_v1.DeploymentStatus{AvailableReplicas: 3},
+			}},
+			workload:          "Deployment/api",
+			expectedAvailable: true,
+			expectedOk:        true,
+		},
+		"A Deployment with an unavailable replica should not be available": {
+			objects: []runtime.Object{&apps_v1.Deployment{
+				ObjectMeta: testOwned("api", "", ""),
+				Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+				Status:     apps_v1.DeploymentStatus{AvailableReplicas: 3, UnavailableReplicas: 1},
+			}},
+			workload:   "Deployment/api",
+			expectedOk: true,
+		},
+		"A DaemonSet scheduled on every node should be available": {
+			objects: []runtime.Object{&apps_v1.DaemonSet{
+				ObjectMeta: testOwned("fluentd", "", ""),
+				Status:     apps_v1.DaemonSetStatus{DesiredNumberScheduled: 4, NumberAvailable: 4},
+			}},
+			workload:          "DaemonSet/fluentd",
+			expectedAvailable: true,
+			expectedOk:        true,
+		},
+		"A Job has no replicas": {
+			objects:  []runtime.Object{&batch_v1.Job{ObjectMeta: testOwned("migrate", "", "")}},
+			workload: "Job/migrate",
+		},
+		"A Deployment that can not be read should not be ok": {
+			workload: "Deployment/api",
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		available, ok := replicasAvailable(fake.NewSimpleClientset(testCase.objects...), "hubbub", testCase.workload)
+		if available != testCase.expectedAvailable || ok != testCase.expectedOk {
+			t.Errorf("Expected available %v (ok %v) but received %v (ok %v)", testCase.expectedAvailable, testCase.expectedOk, available, ok)
+		}
+	}
+
+}
@@ -0,0 +1,133 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// serviceAccountNamespace is the file that holds the namespace of the pod when running in a cluster.
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// StartLeaderElection campaigns for the Lease in the config and runs StartWatcher with the state while this replica is the leader, so only one
+// replica sends notifications. If the lease is lost the watches are stopped and the replica campaigns again as a follower.
+// Its exported as its called by BootStrap and only returns if the watcher returns an error, the elector could not be created or once ctx is done.
+func StartLeaderElection(ctx context.Context, kubeClient kubernetes.Interface, config *models.Config, handler models.NotificationHandler, state *State) error {
+
+	identity, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("unable to get the hostname for the leader election identity : %v", err)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  meta_v1.ObjectMeta{Name: config.LeaderElection.LeaseName, Namespace: leaseNamespace(config)},
+		Client:     kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+
+	for ctx.Err() == nil {
+
+		if err := campaign(ctx, lock, identity, config, func(ctx context.Context) error {
+			return StartWatcher(ctx, kubeClient, config, handler, state)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// campaign runs a single leader election, calling run once the lease is acquired. It returns when the lease is lost or ctx is done,
+// waiting for run to return so that a replica that lost the lease has stopped watching before another one starts. The error from run
+// is returned, in which case the lease is released.
+func campaign(ctx context.Context, lock resourcelock.Interface, identity string, config *models.Config, run func(ctx context.Context) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	leading := make(chan struct{})
+	done := make(chan error, 1)
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            config.LeaderElection.LeaseName,
+		LeaseDuration:   time.Duration(config.LeaderElection.LeaseDuration) * time.Second,
+		RenewDeadline:   time.Duration(config.LeaderElection.RenewDeadline) * time.Second,
+		RetryPeriod:     time.Duration(config.LeaderElection.RetryPeriod) * time.Second,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				fmt.Printf("%v is the leader...\n", identity)
+				close(leading)
+				err := run(ctx)
+				done <- err
+				// a watcher that could not start should not keep the lease from the other replicas
+				if err != nil {
+					cancel()
+				}
+			},
+			// the watcher is stopped by the context passed to OnStartedLeading, it is cancelled when the lease is lost
+			OnStoppedLeading: func() {},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					fmt.Printf("%v is the leader, waiting for the lease...\n", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the leader election : %v", err)
+	}
+
+	fmt.Printf("Waiting to acquire the lease %v...\n", lock.Describe())
+	elector.Run(ctx)
+
+	select {
+	case <-leading:
+	default:
+		return nil // never led
+	}
+
+	if err := <-done; err != nil {
+		return err
+	}
+
+	if ctx.Err() == nil {
+		fmt.Printf("%v lost the lease, the watcher has been stopped\n", identity)
+	}
+
+	return nil
+}
+
+// leaseNamespace returns the namespace of the lease, the one in the config or else the namespace Hubbub is running in.
+func leaseNamespace(config *models.Config) string {
+
+	if config.LeaderElection.LeaseNamespace != "" {
+		return config.LeaderElection.LeaseNamespace
+	}
+
+	return runningNamespace(config)
+}
+
+// runningNamespace returns the namespace Hubbub is running in. Outside of a cluster the first watched namespace is used.
+func runningNamespace(config *models.Config) string {
+
+	if namespace, err := ioutil.ReadFile(serviceAccountNamespace); err == nil && len(namespace) > 0 {
+		return strings.TrimSpace(string(namespace))
+	}
+
+	if config.Namespace != "" {
+		return config.Namespace
+	}
+
+	return "default"
+}
@@ -0,0 +1,177 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	coordination_v1 "k8s.io/api/coordination/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// testLeaseLock returns a Lease lock held as identity.
+func testLeaseLock(kubeClient kubernetes.Interface, identity string) *resourcelock.LeaseLock {
+
+	return &resourcelock.LeaseLock{
+		LeaseMeta:  meta_v1.ObjectMeta{Name: "hubbub", Namespace: "hubbub"},
+		Client:     kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+}
+
+// leaseUpdates counts the updates of the lease made by each identity.
+type leaseUpdates struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// watchLeaseUpdates returns the leaseUpdates of the kubeClient, it counts every update of a lease as it is made.
+func watchLeaseUpdates(kubeClient *fake.Clientset) *leaseUpdates {
+
+	u := &leaseUpdates{counts: map[string]int{}}
+	kubeClient.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if lease, ok := action.(k8stesting.UpdateAction).GetObject().(*coordination_v1.Lease); ok && lease.Spec.HolderIdentity != nil {
+			u.mu.Lock()
+			u.counts[*lease.Spec.HolderIdentity]++
+			u.mu.Unlock()
+		}
+		return false, nil, nil
+	})
+
+	return u
+}
+
+func (u *leaseUpdates) count(identity string) int {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.counts[identity]
+}
+
+// waitForRenewal waits until identity has updated the lease n times and then for a quarter of the retry period. The elector in
+// client-go does not wait for a renewal in flight when it is cancelled, so the test only cancels an elector between two renewals.
+func (u *leaseUpdates) waitForRenewal(t *testing.T, identity string, n int, config *models.Config) {
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return u.count(identity) >= n, nil
+	}); err != nil {
+		t.Errorf("Expected %v to renew the lease", identity) // not Fatalf as it is called from the elector goroutine
+		return
+	}
+
+	time.Sleep(time.Duration(config.LeaderElection.RetryPeriod) * time.Second / 4)
+}
+
+// TestCampaign tests that campaign() only calls run on the replica holding the lease, returns the error from run and
+// releases the lease when run fails so another replica can take over.
+func TestCampaign(t *testing.T) {
+
+	config := &models.Config{Namespace: "hubbub"}
+	config.LeaderElection.LeaseDuration = 3
+	config.LeaderElection.RenewDeadline = 2
+	config.LeaderElection.RetryPeriod = 1
+	config.LoadEnvVars()
+
+	kubeClient := fake.NewSimpleClientset()
+	updates := watchLeaseUpdates(kubeClient)
+
+	// the leader watches until its context is cancelled
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	leading := make(chan struct{})
+	leaderDone := make(chan error, 1)
+	go func() {
+		leaderDone <- campaign(leaderCtx, testLeaseLock(kubeClient, "hubbub-1"), "hubbub-1", config, func(ctx context.Context) error {
+			close(leading)
+			<-ctx.Done()
+			return nil
+		})
+	}()
+
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected hubbub-1 to acquire the lease")
+	}
+
+	t.Logf("\n\nRunning TestCase %v...\n\n", "A follower should not run while the lease is held")
+
+	followerCtx, stopFollower := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer stopFollower()
+	ran := false
+	if err := campaign(followerCtx, testLeaseLock(kubeClient, "hubbub-2"), "hubbub-2", config, func(ctx context.Context) error {
+		ran = true
+		return nil
+	}); err != nil {
+		t.Errorf("Expected no error from the follower but received %v", err)
+	}
+	if ran {
+		t.Errorf("Expected the follower not to run while hubbub-1 holds the lease")
+	}
+
+	t.Logf("\n\nRunning TestCase %v...\n\n", "A leader that stops should release the lease")
+
+	updates.waitForRenewal(t, "hubbub-1", updates.count("hubbub-1")+1, config)
+	stopLeader()
+	if err := <-leaderDone; err != nil {
+		t.Errorf("Expected no error from the leader but received %v", err)
+	}
+
+	t.Logf("\n\nRunning TestCase %v...\n\n", "The next leader should return the error from run")
+
+	expected := errors.New("unable to watch")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := campaign(ctx, testLeaseLock(kubeClient, "hubbub-2"), "hubbub-2", config, func(ctx context.Context) error {
+		// hubbub-2 updates the lease once to acquire it and again to renew it
+		updates.waitForRenewal(t, "hubbub-2", 2, config)
+		return expected
+	}); err != expected {
+		t.Errorf("Expected the error %v but received %v", expected, err)
+	}
+
+}
+
+// TestLeaseNamespace tests the namespace the lease is created in.
+func TestLeaseNamespace(t *testing.T) {
+
+	testSuite := map[string]struct {
+		leaseNamespace string
+		namespace      string
+		expected       string
+	}{
+		"The lease namespace in the config should be used": {
+			leaseNamespace: "kube-system",
+			namespace:      "hubbub",
+			expected:       "kube-system",
+		},
+		"The watched namespace should be used outside of a cluster": {
+			namespace: "hubbub",
+			expected:  "hubbub",
+		},
+		"The default namespace should be used when nothing else is set": {
+			expected: "default",
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		config := &models.Config{Namespace: testCase.namespace}
+		config.LeaderElection.LeaseNamespace = testCase.leaseNamespace
+
+		if namespace := leaseNamespace(config); namespace != testCase.expected {
+			t.Errorf("Expected the namespace %v but received %v", testCase.expected, namespace)
+		}
+	}
+
+}
@@ -0,0 +1,72 @@
+package watcher
+
+import (
+	"strings"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logFetcher attaches the last lines logged by the failed containers of a pod to its PodStatusInformation.
+type logFetcher struct {
+	lines  int64
+	bytes  int64
+	debug  bool
+	redact models.Redactor
+	// get returns the raw logs of a container, it wraps GetLogs so it can be swapped out in the tests
+	get func(namespace, pod string, options *v1.PodLogOptions) ([]byte, error)
+}
+
+// newLogFetcher returns a logFetcher that uses the limits and redaction patterns in the config, nil is returned
+// if logs are not enabled.
+func newLogFetcher(kubeClient kubernetes.Interface, config *models.Config) *logFetcher {
+
+	if !config.Logs.Enabled {
+		return nil
+	}
+
+	return &logFetcher{
+		lines:  config.Logs.Lines,
+		bytes:  config.Logs.Bytes,
+		debug:  config.Debug,
+		redact: config.LogRedactor(),
+		get: func(namespace, pod string, options *v1.PodLogOptions) ([]byte, error) {
+			return kubeClient.CoreV1().Pods(namespace).GetLogs(pod, options).DoRaw()
+		},
+	}
+}
+
+// attach fetches and redacts the logs of every failed container in p. A container in CrashLoopBackOff is waiting to be restarted
+// so the logs of the previous (terminated) instance are fetched. Containers that never ran (image pulls) are skipped and an error
+// fetching the logs is only logged, the notification is still sent without them.
+func (l *logFetcher) attach(p *models.PodStatusInformation) {
+
+	if l == nil || p.InvolvedObject != "" {
+		return
+	}
+
+	for i, f := range p.Failures {
+
+		if f.IsImagePull() {
+			continue
+		}
+
+		lines, bytes := l.lines, l.bytes
+		options := &v1.PodLogOptions{
+			Container:  f.ContainerName,
+			Previous:   f.Reason == models.CrashLoopBackOff,
+			TailLines:  &lines,
+			LimitBytes: &bytes,
+		}
+
+		logs, err := l.get(p.Namespace, p.PodName, options)
+		if err != nil {
+			helpers.DebugLog(l.debug, "Unable to get the logs for container "+f.ContainerName+" in pod "+p.PodName+" : "+err.Error())
+			continue
+		}
+
+		p.Failures[i].Logs = l.redact(strings.TrimRight(string(logs), "\n"))
+	}
+}
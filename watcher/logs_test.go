@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"fmt"
+	"testing"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+)
+
+// TestAttachLogs tests attach() on the logFetcher, verifying the options passed to GetLogs and that the logs are redacted.
+func TestAttachLogs(t *testing.T) {
+
+	testSuite := map[string]struct {
+		failure          models.ContainerFailure
+		logs             string
+		err              error
+		expectedLogs     string
+		expectedPrevious bool
+		expectFetch      bool
+	}{
+		"A terminated container should have its current logs attached": {
+			failure:      models.ContainerFailure{ContainerName: "api", Reason: "Error", ExitCode: 1},
+			logs:         "starting\npanic: token=abc123 is invalid\n",
+			expectedLogs: "starting\npanic: [REDACTED] is invalid",
+			expectFetch:  true,
+		},
+		"A container in CrashLoopBackOff should have the logs of its previous instance attached": {
+			failure:          models.ContainerFailure{ContainerName: "api", Reason: models.CrashLoopBackOff, ExitCode: 1},
+			logs:             "exit status 1",
+			expectedLogs:     "exit status 1",
+			expectedPrevious: true,
+			expectFetch:      true,
+		},
+		"A container that could not pull its image should be skipped": {
+			failure: models.ContainerFailure{ContainerName: "api", Reason: "ErrImagePull", ExitCode: -1},
+		},
+		"An error getting the logs should leave them empty": {
+			failure:     models.ContainerFailure{ContainerName: "api", Reason: "Error", ExitCode: 1},
+			err:         fmt.Errorf("previous terminated container \"api\" not found"),
+			expectFetch: true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		config := &models.Config{Namespace: "hubbub"}
+		config.Logs.Enabled = true
+		config.Logs.Redact = []string{`token=\S+`}
+		config.LoadEnvVars()
+
+		var received *v1.PodLogOptions
+		fetcher := newLogFetcher(nil, config)
+		fetcher.get = func(namespace, pod string, options *v1.PodLogOptions) ([]byte, error) {
+			received = options
+			return []byte(testCase.logs), testCase.err
+		}
+
+		p := models.PodStatusInformation{Namespace: "hubbub", PodName: "api-1", Failures: []models.ContainerFailure{testCase.failure}}
+		fetcher.attach(&p)
+
+		if p.Failures[0].Logs != testCase.expectedLogs {
+			t.Errorf("Expected the logs %q but received %q", testCase.expectedLogs, p.Failures[0].Logs)
+		}
+
+		if (received != nil) != testCase.expectFetch {
+			t.Fatalf("Expected the logs to be fetched : %v", testCase.expectFetch)
+		}
+
+		if received == nil {
+			continue
+		}
+
+		if received.Container != "api" || received.Previous != testCase.expectedPrevious {
+			t.Errorf("Expected the container api with previous %v but received %v with previous %v", testCase.expectedPrevious, received.Container, received.Previous)
+		}
+
+		if *received.TailLines != config.Logs.Lines || *received.LimitBytes != config.Logs.Bytes {
+			t.Errorf("Expected the limits %v lines and %v bytes but received %v and %v", config.Logs.Lines, config.Logs.Bytes, *received.TailLines, *received.LimitBytes)
+		}
+	}
+
+}
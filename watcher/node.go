@@ -0,0 +1,72 @@
+package watcher
+
+import (
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeCacheTTL is how long a node is cached for. It is kept short as the conditions of a node under pressure change quickly,
+// the cache only saves the API server from a burst of failures on the same node (e.g. an eviction wave).
+const nodeCacheTTL = 30 * time.Second
+
+// cachedNode is a node in the nodeGetter cache.
+type cachedNode struct {
+	node    models.NodeInformation
+	expires time.Time
+}
+
+// nodeGetter gets the nodes that failed pods ran on, caching them for nodeCacheTTL.
+// Each pod watch has its own nodeGetter so the cache is not shared between goroutines.
+type nodeGetter struct {
+	kubeClient kubernetes.Interface
+	debug      bool
+	cache      map[string]cachedNode
+}
+
+// newNodeGetter returns a nodeGetter with an empty cache.
+func newNodeGetter(kubeClient kubernetes.Interface, debug bool) *nodeGetter {
+	return &nodeGetter{kubeClient: kubeClient, debug: debug, cache: map[string]cachedNode{}}
+}
+
+// attach loads the node the pod in p ran on. Nothing is attached if the pod was never scheduled or the node can not be read,
+// the node name alone is still in the notification.
+func (n *nodeGetter) attach(p *models.PodStatusInformation) {
+
+	if p.NodeName == "" {
+		return
+	}
+
+	if cached, ok := n.cache[p.NodeName]; ok && time.Now().Before(cached.expires) {
+		node := cached.node
+		p.Node = &node
+		return
+	}
+
+	node, err := n.kubeClient.CoreV1().Nodes().Get(p.NodeName, meta_v1.GetOptions{})
+	if err != nil {
+		helpers.DebugLog(n.debug, "Unable to get the node "+p.NodeName+" : "+err.Error())
+		return
+	}
+
+	information := models.NodeInformation{}
+	information.Load(node)
+
+	n.prune()
+	n.cache[p.NodeName] = cachedNode{node: information, expires: time.Now().Add(nodeCacheTTL)}
+	p.Node = &information
+}
+
+// prune removes the expired nodes from the cache.
+func (n *nodeGetter) prune() {
+
+	now := time.Now()
+	for name, cached := range n.cache {
+		if now.After(cached.expires) {
+			delete(n.cache, name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"testing"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestNodeGetter tests attach() on the nodeGetter, verifying the node is loaded and that repeated lookups are served from the cache.
+func TestNodeGetter(t *testing.T) {
+
+	testSuite := map[string]struct {
+		nodeName        string
+		expectedNode    bool
+		expectedActions int
+	}{
+		"The node of a scheduled pod should be attached and cached": {
+			nodeName:        "node-1",
+			expectedNode:    true,
+			expectedActions: 1,
+		},
+		"A node that can not be read should not be attached": {
+			nodeName:        "node-2",
+			expectedActions: 2,
+		},
+		"A pod that was never scheduled should not look up a node": {},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		client := fake.NewSimpleClientset(&v1.Node{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "node-1", Labels: map[string]string{"topology.kubernetes.io/zone": "eastus-1"}},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue}}},
+		})
+		n := newNodeGetter(client, false)
+
+		// the second lookup should come from the cache
+		for i := 0; i < 2; i++ {
+
+			p := models.PodStatusInformation{PodName: "api-1", NodeName: testCase.nodeName}
+			n.attach(&p)
+
+			if (p.Node != nil) != testCase.expectedNode {
+				t.Fatalf("Expected the node to be attached : %v", testCase.expectedNode)
+			}
+
+			if p.Node != nil && (p.Node.Zone != "eastus-1" || p.Node.MemoryPressure != "True") {
+				t.Errorf("Expected the node to be loaded but received %+v", p.Node)
+			}
+		}
+
+		if len(client.Actions()) != testCase.expectedActions {
+			t.Errorf("Expected %v calls to the API server but received %v", testCase.expectedActions, len(client.Actions()))
+		}
+	}
+
+}
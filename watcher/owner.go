@@ -0,0 +1,164 @@
+package watcher
+
+import (
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ownerCacheTTL is how long a resolved owner is cached for. The owner of a ReplicaSet or Job does not change, the TTL
+// stops the cache from growing forever as deployments roll out new ReplicaSets and picks up changes to the annotations.
+const ownerCacheTTL = 10 * time.Minute
+
+// owner is the workload a pod belongs to, e.g. Deployment/api, and the hubbub.io annotations set on it. labels are the labels
+// of the pod, they are only set by resolveName.
+type owner struct {
+	kind        string
+	name        string
+	annotations map[string]string
+	labels      map[string]string
+	expires     time.Time
+}
+
+// ownerResolver walks the ownerReferences of a pod up to the workload that created it. A ReplicaSet is resolved to its
+// Deployment and a Job to its CronJob, the lookups are cached so the API server is not hit for every pod change.
+// Each watch has its own ownerResolver so the cache is not shared between goroutines.
+type ownerResolver struct {
+	kubeClient kubernetes.Interface
+	debug      bool
+	cache      map[string]owner
+}
+
+// newOwnerResolver returns an ownerResolver with an empty cache.
+func newOwnerResolver(kubeClient kubernetes.Interface, debug bool) *ownerResolver {
+	return &ownerResolver{kubeClient: kubeClient, debug: debug, cache: map[string]owner{}}
+}
+
+// resolve returns the workload that owns the pod, the kind and name are empty for a pod that has no controller.
+// If the intermediate owner (a ReplicaSet or Job) can not be read it is returned as the owner.
+func (o *ownerResolver) resolve(pod *v1.Pod) owner {
+
+	ref := meta_v1.GetControllerOf(pod)
+	if ref == nil {
+		return owner{}
+	}
+
+	key := pod.Namespace + "/" + ref.Kind + "/" + ref.Name
+	if cached, ok := o.cache[key]; ok && time.Now().Before(cached.expires) {
+		return cached
+	}
+
+	resolved := owner{kind: ref.Kind, name: ref.Name, expires: time.Now().Add(ownerCacheTTL)}
+
+	// StatefulSets, DaemonSets and any other controller own the pod directly
+	if ref.Kind == "ReplicaSet" || ref.Kind == "Job" {
+
+		parent, err := o.parent(pod.Namespace, ref)
+		if err != nil {
+			helpers.DebugLog(o.debug, "Unable to get the owner of "+ref.Kind+"/"+ref.Name+" : "+err.Error())
+			return owner{kind: ref.Kind, name: ref.Name}
+		}
+
+		if parent != nil {
+			resolved.kind, resolved.name = parent.Kind, parent.Name
+		}
+	}
+
+	annotations, err := o.annotations(pod.Namespace, resolved.kind, resolved.name)
+	if err != nil {
+		helpers.DebugLog(o.debug, "Unable to get the annotations of "+resolved.kind+"/"+resolved.name+" : "+err.Error())
+	}
+	resolved.annotations = models.MergeAnnotations(annotations, nil)
+
+	o.prune()
+	o.cache[key] = resolved
+
+	return resolved
+}
+
+// resolveName resolves the owner of a pod that is only known by name, such as the pod a warning event is about. The annotations
+// of the returned owner include the ones on the pod, and its labels are the labels of the pod.
+func (o *ownerResolver) resolveName(namespace, podName string) owner {
+
+	key := namespace + "/Pod/" + podName
+	if cached, ok := o.cache[key]; ok && time.Now().Before(cached.expires) {
+		return cached
+	}
+
+	pod, err := o.kubeClient.CoreV1().Pods(namespace).Get(podName, meta_v1.GetOptions{})
+	if err != nil {
+		helpers.DebugLog(o.debug, "Unable to get the pod "+podName+" : "+err.Error())
+		return owner{}
+	}
+
+	resolved := o.resolve(pod)
+	resolved.annotations = models.MergeAnnotations(resolved.annotations, pod.Annotations)
+	resolved.labels = pod.Labels
+	resolved.expires = time.Now().Add(ownerCacheTTL)
+	o.cache[key] = resolved
+
+	return resolved
+}
+
+// parent returns the controller of a ReplicaSet or Job, nil is returned if it has none.
+func (o *ownerResolver) parent(namespace string, ref *meta_v1.OwnerReference) (*meta_v1.OwnerReference, error) {
+
+	if ref.Kind == "ReplicaSet" {
+		rs, err := o.kubeClient.AppsV1().ReplicaSets(namespace).Get(ref.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return meta_v1.GetControllerOf(rs), nil
+	}
+
+	job, err := o.kubeClient.BatchV1().Jobs(namespace).Get(ref.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return meta_v1.GetControllerOf(job), nil
+}
+
+// annotations returns the annotations of a workload, kinds that are not known to Hubbub have none.
+func (o *ownerResolver) annotations(namespace, kind, name string) (map[string]string, error) {
+
+	var object meta_v1.Object
+	var err error
+
+	switch kind {
+	case "Deployment":
+		object, err = o.kubeClient.AppsV1().Deployments(namespace).Get(name, meta_v1.GetOptions{})
+	case "StatefulSet":
+		object, err = o.kubeClient.AppsV1().StatefulSets(namespace).Get(name, meta_v1.GetOptions{})
+	case "DaemonSet":
+		object, err = o.kubeClient.AppsV1().DaemonSets(namespace).Get(name, meta_v1.GetOptions{})
+	case "ReplicaSet":
+		object, err = o.kubeClient.AppsV1().ReplicaSets(namespace).Get(name, meta_v1.GetOptions{})
+	case "Job":
+		object, err = o.kubeClient.BatchV1().Jobs(namespace).Get(name, meta_v1.GetOptions{})
+	case "CronJob":
+		object, err = o.kubeClient.BatchV1beta1().CronJobs(namespace).Get(name, meta_v1.GetOptions{})
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return object.GetAnnotations(), nil
+}
+
+// prune removes the expired entries from the cache.
+func (o *ownerResolver) prune() {
+
+	now := time.Now()
+	for key, cached := range o.cache {
+		if now.After(cached.expires) {
+			delete(o.cache, key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package watcher
+
+import (
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testOwned returns the metadata of an object in the hubbub namespace that is controlled by kind/name, or has no controller if kind is empty.
+func testOwned(name, kind, ownerName string) meta_v1.ObjectMeta {
+
+	meta := meta_v1.ObjectMeta{Name: name, Namespace: "hubbub"}
+	if kind != "" {
+		controller := true
+		meta.OwnerReferences = []meta_v1.OwnerReference{{Kind: kind, Name: ownerName, Controller: &controller}}
+	}
+
+	return meta
+}
+
+// TestOwnerResolver tests resolve() on the ownerResolver, which walks the ownerReferences of a pod up to its workload.
+func TestOwnerResolver(t *testing.T) {
+
+	testSuite := map[string]struct {
+		pod          *v1.Pod
+		objects      []runtime.Object
+		expectedKind string
+		expectedName string
+	}{
+		"A pod created by a ReplicaSet should resolve to its Deployment": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("api-7c9f8d6b5-xk2lp", "ReplicaSet", "api-7c9f8d6b5")},
+			objects:      []runtime.Object{&apps_v1.ReplicaSet{ObjectMeta: testOwned("api-7c9f8d6b5", "Deployment", "api")}},
+			expectedKind: "Deployment",
+			expectedName: "api",
+		},
+		"A pod created by a Job should resolve to its CronJob": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("backup-1576108800-abcde", "Job", "backup-1576108800")},
+			objects:      []runtime.Object{&batch_v1.Job{ObjectMeta: testOwned("backup-1576108800", "CronJob", "backup")}},
+			expectedKind: "CronJob",
+			expectedName: "backup",
+		},
+		"A pod created by a Job without a CronJob should resolve to the Job": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("migrate-abcde", "Job", "migrate")},
+			objects:      []runtime.Object{&batch_v1.Job{ObjectMeta: testOwned("migrate", "", "")}},
+			expectedKind: "Job",
+			expectedName: "migrate",
+		},
+		"A pod created by a StatefulSet should resolve to the StatefulSet": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("redis-0", "StatefulSet", "redis")},
+			expectedKind: "StatefulSet",
+			expectedName: "redis",
+		},
+		"A pod created by a DaemonSet should resolve to the DaemonSet": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("fluentd-x2k9p", "DaemonSet", "fluentd")},
+			expectedKind: "DaemonSet",
+			expectedName: "fluentd",
+		},
+		"A ReplicaSet that can not be read should be returned as the owner": {
+			pod:          &v1.Pod{ObjectMeta: testOwned("api-7c9f8d6b5-xk2lp", "ReplicaSet", "api-7c9f8d6b5")},
+			expectedKind: "ReplicaSet",
+			expectedName: "api-7c9f8d6b5",
+		},
+		"A bare pod should have no owner": {
+			pod: &v1.Pod{ObjectMeta: testOwned("debug", "", "")},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		o := newOwnerResolver(fake.NewSimpleClientset(testCase.objects...), false)
+		if resolved := o.resolve(testCase.pod); resolved.kind != testCase.expectedKind || resolved.name != testCase.expectedName {
+			t.Errorf("Expected %v/%v but received %v/%v", testCase.expectedKind, testCase.expectedName, resolved.kind, resolved.name)
+		}
+	}
+
+}
+
+// TestOwnerResolverCache verifies that a resolved owner and its annotations are served from the cache instead of the API server.
+func TestOwnerResolverCache(t *testing.T) {
+
+	deployment := &apps_v1.Deployment{ObjectMeta: testOwned("api", "", "")}
+	deployment.Annotations = map[string]string{"hubbub.io/channel": "#payments", "deployment.kubernetes.io/revision": "4"}
+	client := fake.NewSimpleClientset(&apps_v1.ReplicaSet{ObjectMeta: testOwned("api-7c9f8d6b5", "Deployment", "api")}, deployment)
+	o := newOwnerResolver(client, false)
+
+	for _, name := range []string{"api-7c9f8d6b5-xk2lp", "api-7c9f8d6b5-p9w2z", "api-7c9f8d6b5-xk2lp"} {
+
+		resolved := o.resolve(&v1.Pod{ObjectMeta: testOwned(name, "ReplicaSet", "api-7c9f8d6b5")})
+		if resolved.kind != "Deployment" || resolved.name != "api" {
+			t.Errorf("Expected Deployment/api but received %v/%v", resolved.kind, resolved.name)
+		}
+
+		// only the hubbub.io annotations are kept
+		if len(resolved.annotations) != 1 || resolved.annotations["hubbub.io/channel"] != "#payments" {
+			t.Errorf("Expected the channel annotation of the deployment but received %v", resolved.annotations)
+		}
+	}
+
+	// one call for the ReplicaSet and one for the Deployment
+	if len(client.Actions()) != 2 {
+		t.Errorf("Expected two calls to the API server but received %v", len(client.Actions()))
+	}
+
+}
@@ -0,0 +1,194 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchBackoff is the backoff used when a watch could not be (re)created or closes without delivering any events.
+// It starts at one second and doubles up to a minute.
+var watchBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      time.Minute,
+}
+
+// resumableWatch lists a resource and then watches it from the resourceVersion of the list. The resourceVersion is kept
+// between watches so that a new watch resumes where the last one stopped and no changes are missed.
+type resumableWatch struct {
+	// kind and namespace are only used in the console output
+	kind      string
+	namespace string
+	debug     bool
+	options   meta_v1.ListOptions
+	// list returns the resourceVersion of the list and its items, watch creates the watch.Interface{}
+	list  func(options meta_v1.ListOptions) (string, []runtime.Object, error)
+	watch func(options meta_v1.ListOptions) (watch.Interface, error)
+	// handle is called for every Added/Modified event, resync for every item listed after the resourceVersion expired
+	handle func(eventType watch.EventType, obj runtime.Object)
+	resync func(obj runtime.Object)
+	// backfill, if set, is called with the items of the initial list
+	backfill func(items []runtime.Object)
+
+	resourceVersion string
+}
+
+// run lists the resource to get the current resourceVersion and then watches from that version. When the watch
+// closes it is recreated from the last resourceVersion seen, backing off if the watch can not be created or closed without any events. If the resourceVersion
+// has expired (410 Gone) the resource is listed again and every item is passed to resync so nothing that happened between the watches is lost.
+//
+// The items of the initial list are passed to backfill when it is set. An error is only returned if the initial list fails, this
+// generally means the service account is missing permissions.
+// nil is returned once ctx is done.
+func (r *resumableWatch) run(ctx context.Context) error {
+
+	items, err := r.listItems()
+	if err != nil {
+		return fmt.Errorf("error creating watcher : %v", err)
+	}
+
+	if r.backfill != nil {
+		r.backfill(items)
+	}
+
+	backoff := watchBackoff
+	for {
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		options := r.options
+		options.ResourceVersion = r.resourceVersion
+		options.AllowWatchBookmarks = true
+
+		watcher, err := r.watch(options)
+
+		if err == nil {
+
+			helpers.DebugLog(r.debug, "Watcher created for "+r.kind+" in namespace '"+r.namespace+"' at resourceVersion "+r.resourceVersion)
+			var received bool
+			received, err = r.consume(ctx, watcher)
+			if received {
+				backoff = watchBackoff
+			} else if err == nil {
+				// the watch closed without any events, waiting keeps an API server that closes every watch from being hammered
+				helpers.DebugLog(r.debug, "The watch of "+r.kind+" in namespace '"+r.namespace+"' closed without any events, backing off")
+				sleep(ctx, backoff.Step())
+			}
+		}
+
+		if err != nil {
+
+			if isExpired(err) {
+				helpers.DebugLog(r.debug, "The resourceVersion "+r.resourceVersion+" has expired, listing the "+r.kind+" in '"+r.namespace+"' again")
+				if err := r.relist(); err == nil {
+					backoff = watchBackoff
+					continue
+				}
+			}
+
+			fmt.Printf("Error watching %v in namespace '%v' : %v\n", r.kind, r.namespace, err) // non termintating
+			sleep(ctx, backoff.Step())
+		}
+	}
+}
+
+// listItems lists the resource and stores the resourceVersion of the list so the next watch starts from it.
+func (r *resumableWatch) listItems() ([]runtime.Object, error) {
+
+	resourceVersion, items, err := r.list(r.options)
+	if err != nil {
+		return nil, err
+	}
+
+	r.resourceVersion = resourceVersion
+	return items, nil
+}
+
+// relist lists the resource after the resourceVersion expired and passes each item to resync.
+func (r *resumableWatch) relist() error {
+
+	items, err := r.listItems()
+	if err != nil {
+		fmt.Printf("Error listing %v in namespace '%v' : %v\n", r.kind, r.namespace, err) // non termintating
+		return err
+	}
+
+	for _, item := range items {
+		r.resync(item)
+	}
+
+	return nil
+}
+
+// consume loops until the channel in the watch interface is closed or ctx is done, passing Added and Modified events to handle and
+// updating the resourceVersion as events arrive.
+//
+// received is true if at least one event was seen, err is set if the watch ended with an error event (e.g. a 410 Gone).
+func (r *resumableWatch) consume(ctx context.Context, watcher watch.Interface) (received bool, err error) {
+
+	defer watcher.Stop()
+
+	for {
+		select {
+
+		case <-ctx.Done():
+			return received, nil
+
+		case e, ok := <-watcher.ResultChan():
+
+			if !ok {
+				helpers.DebugLog(r.debug, "The channel has been closed, attempting to recreate the watcher")
+				return received, nil
+			}
+
+			received = true
+
+			if e.Type == watch.Error {
+				return received, errors.FromObject(e.Object)
+			}
+
+			if accessor, err := meta.Accessor(e.Object); err == nil {
+				r.resourceVersion = accessor.GetResourceVersion()
+			}
+
+			if e.Type == watch.Added || e.Type == watch.Modified {
+				r.handle(e.Type, e.Object)
+			}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// isExpired reports if err is a 410, meaning the resourceVersion is too old to resume the watch from.
+func isExpired(err error) bool {
+
+	if errors.IsGone(err) || errors.IsResourceExpired(err) {
+		return true
+	}
+
+	status, ok := err.(errors.APIStatus)
+	return ok && status.Status().Code == http.StatusGone
+}
@@ -0,0 +1,188 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// scriptedWatch is a single step of the watch reactor in testRun, either the events delivered before the watch closes or an error
+// returned when the watch is created.
+type scriptedWatch struct {
+	events []watch.Event
+	err    error
+}
+
+// testRunResult is what testRun saw while the pod watch was running.
+type testRunResult struct {
+	// watchVersions is the resourceVersion of every watch, watchTimes is when each one was created
+	watchVersions []string
+	watchTimes    []time.Time
+	lists         int
+	notifications int
+}
+
+// testRun runs a pod watch created by newPodWatch() against a fake clientset. Each list returns the next of the lists resourceVersions
+// and the pods, each watch plays the next step of the script. Once the script is done the watch is cancelled.
+func testRun(t *testing.T, lists []string, pods []v1.Pod, script []scriptedWatch) testRunResult {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := testRunResult{}
+	client := fake.NewSimpleClientset()
+
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		resourceVersion := lists[len(lists)-1]
+		if result.lists < len(lists) {
+			resourceVersion = lists[result.lists]
+		}
+		result.lists++
+		return true, &v1.PodList{ListMeta: meta_v1.ListMeta{ResourceVersion: resourceVersion}, Items: pods}, nil
+	})
+
+	client.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		step := len(result.watchVersions)
+		result.watchVersions = append(result.watchVersions, action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion)
+		result.watchTimes = append(result.watchTimes, time.Now())
+
+		if step >= len(script) {
+			cancel()
+			return true, testWatch(nil), nil
+		}
+		if script[step].err != nil {
+			return true, nil, script[step].err
+		}
+		return true, testWatch(script[step].events), nil
+	})
+
+	handler := &countingHandler{}
+	config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+	config.LoadEnvVars()
+	w := newPodWatch(client, "hubbub", config, handler, testState(config, handler))
+
+	done := make(chan error)
+	go func() {
+		done <- w.run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Error on run() %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("run() did not return after the script was done")
+	}
+
+	result.notifications = handler.count
+	return result
+}
+
+// TestResumableWatchRun tests run() against a scripted watch, verifying the watches resume from the last resourceVersion seen and
+// that an expired resourceVersion lists the pods again, resyncs them and watches from the version of the new list.
+func TestResumableWatchRun(t *testing.T) {
+
+	defer func(b wait.Backoff) { watchBackoff = b }(watchBackoff)
+	watchBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 1}
+
+	testSuite := map[string]struct {
+		lists                 []string
+		pods                  []v1.Pod
+		script                []scriptedWatch
+		expectedVersions      []string
+		expectedLists         int
+		expectedNotifications int
+	}{
+		"A closed watch should resume from the last resourceVersion": {
+			lists: []string{"10"},
+			script: []scriptedWatch{
+				{events: []watch.Event{{Type: watch.Added, Object: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "api-1", ResourceVersion: "12"}}}}},
+				{events: []watch.Event{{Type: watch.Added, Object: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "api-1", ResourceVersion: "15"}}}}},
+			},
+			expectedVersions: []string{"10", "12", "15"},
+			expectedLists:    1,
+		},
+		"A watch that could not be created should be retried from the same resourceVersion": {
+			lists: []string{"10"},
+			script: []scriptedWatch{
+				{err: errors.NewServiceUnavailable("unavailable")},
+			},
+			expectedVersions: []string{"10", "10"},
+			expectedLists:    1,
+		},
+		"An expired resourceVersion should relist, resync and watch from the new list": {
+			lists: []string{"10", "40"},
+			pods:  []v1.Pod{*testFailedPod("api-1", "39")},
+			script: []scriptedWatch{
+				{events: []watch.Event{{Type: watch.Error, Object: &errors.NewResourceExpired("too old resource version").ErrStatus}}},
+			},
+			expectedVersions:      []string{"10", "40"},
+			expectedLists:         2,
+			expectedNotifications: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		result := testRun(t, testCase.lists, testCase.pods, testCase.script)
+
+		if len(result.watchVersions) != len(testCase.expectedVersions) {
+			t.Fatalf("Expected the watches %v but received %v", testCase.expectedVersions, result.watchVersions)
+		}
+		for i, v := range testCase.expectedVersions {
+			if result.watchVersions[i] != v {
+				t.Errorf("Expected watch %v to start at resourceVersion %v but received %v", i, v, result.watchVersions[i])
+			}
+		}
+
+		if result.lists != testCase.expectedLists {
+			t.Errorf("Expected %v list(s) but received %v", testCase.expectedLists, result.lists)
+		}
+
+		if result.notifications != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, result.notifications)
+		}
+	}
+
+}
+
+// TestResumableWatchBackoff tests that run() backs off between watches that close without delivering any events, and that a watch
+// delivering events resets the backoff.
+func TestResumableWatchBackoff(t *testing.T) {
+
+	defer func(b wait.Backoff) { watchBackoff = b }(watchBackoff)
+	watchBackoff = wait.Backoff{Duration: 50 * time.Millisecond, Factor: 2, Steps: 10}
+
+	event := []watch.Event{{Type: watch.Added, Object: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "api-1", ResourceVersion: "12"}}}}
+	result := testRun(t, []string{"10"}, nil, []scriptedWatch{{}, {}, {events: event}})
+
+	// watch 0 and 1 close empty and back off 50ms then 100ms, watch 2 delivers an event so watch 3 follows straight away
+	expected := []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}
+	if len(result.watchTimes) != 4 {
+		t.Fatalf("Expected 4 watches but received %v", len(result.watchTimes))
+	}
+
+	for i, d := range expected {
+		if gap := result.watchTimes[i+1].Sub(result.watchTimes[i]); gap < d {
+			t.Errorf("Expected at least %v between watch %v and %v but received %v", d, i, i+1, gap)
+		}
+	}
+
+	if gap := result.watchTimes[3].Sub(result.watchTimes[2]); gap >= 50*time.Millisecond {
+		t.Errorf("Expected no backoff after a watch with events but waited %v", gap)
+	}
+
+}
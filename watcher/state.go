@@ -0,0 +1,253 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/helpers"
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
+	"gihutb.com/jxmoore/hubbub/store"
+	"k8s.io/client-go/kubernetes"
+)
+
+// storeSaveInterval is how often the state is saved while it is changing. A restart loses at most this much of the state.
+const storeSaveInterval = 30 * time.Second
+
+// State is the state shared by every watch started by StartWatcher. It is created once by BootStrap and kept across the runs of
+// StartWatcher, so a config reload or a new term as the leader keeps the dedup cache, the incidents and their escalations and the
+// digest counts. The parts derived from the config are swapped in by each run.
+type State struct {
+	kubeClient kubernetes.Interface
+	handler    models.NotificationHandler
+
+	dedup     *dedupCache
+	incidents *incidentTracker
+	// silences is shared with the silence API, the runtime silences are saved with the rest of the state
+	silences *silence.Registry
+	// summary is the backfill summary, it is nil unless the backfill mode is report
+	summary *backfillSummary
+	// group buffers the pod failures, it is nil unless grouping is enabled
+	group *grouper
+	// escalations are the escalation policies for the open incidents
+	escalations []models.EscalationPolicy
+	// digest counts the failures for the digest, it is nil unless a digest period is set
+	digest *digestRecorder
+	// store persists the state, it is nil when the state is only kept in memory. saving stops the final save from
+	// running at the same time as a periodic one.
+	store  store.Store
+	debug  bool
+	saving sync.Mutex
+}
+
+// NewState returns an empty State that shares the silences with the silence API. Its exported as its created by BootStrap and
+// passed to StartWatcher, which configures it for each run.
+func NewState(kubeClient kubernetes.Interface, silences *silence.Registry) *State {
+
+	return &State{
+		kubeClient: kubeClient,
+		dedup:      newDedupCache(0, dedupCacheSize),
+		incidents:  newIncidentTracker(incidentLimit),
+		silences:   silences,
+	}
+}
+
+// configure swaps in the parts of the state derived from the config and handler, the dedup window is the time in the config. It
+// is only called while no watches are running. If a store is configured the saved state is loaded from it and merged into the state
+// in memory, another replica may have been the leader since it was last saved. A state that can not be loaded is only logged.
+func (s *State) configure(config *models.Config, handler models.NotificationHandler) error {
+
+	s.handler = handler
+	s.escalations = config.Escalations
+	s.debug = config.Debug
+	s.dedup.setWindow(time.Duration(config.TimeCheck) * time.Minute)
+
+	if config.Digest.Period == "" {
+		s.digest = nil
+	} else if s.digest == nil {
+		s.digest = newDigestRecorder(config, handler, time.Now())
+	} else {
+		s.digest.configure(config, handler)
+	}
+
+	// the pods already failed in every namespace are reported in a single summary
+	s.summary = nil
+	if config.Backfill == models.BackfillReport {
+		s.summary = newBackfillSummary(handler, len(config.WatchedNamespaces()))
+	}
+
+	// the group of the last run was flushed when its watches stopped
+	s.group = nil
+	if config.Grouping.Window > 0 {
+		s.group = newGrouper(time.Duration(config.Grouping.Window)*time.Second, time.Duration(config.Grouping.MaxWait)*time.Second, handler, s.notified, config.Debug)
+	}
+
+	switch config.Store.Type {
+	case models.StoreFile:
+		s.store = store.NewFileStore(config.Store.Path)
+	case models.StoreConfigMap:
+		namespace, name := runningNamespace(config), config.Store.ConfigMap
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+		s.store = store.NewConfigMapStore(s.kubeClient, namespace, name)
+	case "":
+		s.store = nil
+		return nil
+	default:
+		return fmt.Errorf("unknown store type %v", config.Store.Type)
+	}
+
+	saved, err := s.store.Load()
+	if err != nil {
+		fmt.Printf("Unable to load the saved state, starting without it : %v\n", err)
+		return nil
+	}
+
+	s.dedup.restore(saved.Dedup)
+	s.incidents.restore(saved.Incidents)
+	s.silences.Restore(saved.Silences)
+	helpers.DebugLog(config.Debug, fmt.Sprintf("Loaded %v dedup key(s), %v incident(s) and %v silence(s) from the store", len(saved.Dedup), len(saved.Incidents),
+		len(saved.Silences)))
+
+	return nil
+}
+
+// persist saves the state every interval, if it has changed, until ctx is done.
+func (s *State) persist(ctx context.Context, interval time.Duration) {
+
+	if s.store == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.save()
+		}
+	}
+}
+
+// save saves the state if it has changed since the last save, an error is only logged and the state is saved again next time.
+func (s *State) save() {
+
+	if s.store == nil {
+		return
+	}
+
+	s.saving.Lock()
+	defer s.saving.Unlock()
+
+	dedup, dedupChanged := s.dedup.snapshot()
+	incidents, incidentsChanged := s.incidents.snapshot()
+	silences, silencesChanged := s.silences.Snapshot()
+	if !dedupChanged && !incidentsChanged && !silencesChanged {
+		return
+	}
+
+	if err := s.store.Save(&store.State{Dedup: dedup, Incidents: incidents, Silences: silences}); err != nil {
+		fmt.Printf("Unable to save the state : %v\n", err) // non termintating
+		s.dedup.touch()
+		s.incidents.touch()
+		s.silences.Touch()
+		return
+	}
+
+	helpers.DebugLog(s.debug, fmt.Sprintf("Saved %v dedup key(s), %v incident(s) and %v silence(s) to the store", len(dedup), len(incidents), len(silences)))
+}
+
+// silenced reports if the failures in p match a silence, the match is logged with the silence. The failures are recorded in the
+// dedup cache so the silence is not logged again for every change to the pod, they do not open an incident as nothing was sent.
+func (s *State) silenced(p models.PodStatusInformation) bool {
+
+	matched, ok := s.silences.Match(p)
+	if !ok {
+		return false
+	}
+
+	fmt.Printf("Suppressed the notification for %v in namespace %v, it matched the silence %v\n", p.PodName, p.Namespace, matched)
+	s.dedup.notified(p)
+
+	return true
+}
+
+// notified records a notification that was sent for p, in the dedup cache and as an incident for its workload.
+func (s *State) notified(p models.PodStatusInformation) {
+
+	s.dedup.notified(p)
+	s.incidents.open(p)
+}
+
+// recovered records that the workload of the incident for the key is healthy at 'now', the incident is resolved once the workload
+// has been healthy for the recovery period. Until then its dedup keys are kept, a failure during the period is a repeat of the
+// same incident and restarts its recovery.
+func (s *State) recovered(key string, now time.Time) {
+
+	since, ok := s.incidents.recovering(key, now)
+	if !ok {
+		return
+	}
+
+	if now.Sub(since) < recoveryPeriod {
+		helpers.DebugLog(s.debug, "The workload for "+key+" is healthy, its incident is resolved if it stays healthy until "+since.Add(recoveryPeriod).String())
+		return
+	}
+
+	s.resolve(key)
+}
+
+// resolve resolves the incident for the key and sends the resolved notification, which cancels any escalation steps that are
+// left. The dedup keys of the workload are dropped so that if it fails again the failure is notified, rather than counted as a
+// repeat of the failure that was resolved.
+func (s *State) resolve(key string) {
+
+	incident := s.incidents.resolve(key)
+	if incident == nil {
+		return
+	}
+
+	s.dedup.forget(key + "/")
+	helpers.DebugLog(s.debug, "The incident for "+key+" is resolved after "+incident.TimeToRecovery().String())
+
+	if err := helpers.NewResolvedNotification(s.handler, *incident); err != nil {
+		fmt.Println(err.Error()) // non termintating
+	}
+
+	s.resolveEscalation(*incident)
+}
+
+// checkIncidents checks the workloads with an open incident every interval until ctx is done, the incident is resolved once
+// every replica of the workload has been available for the recovery period. Workloads without replicas only start their recovery
+// from the pod watches, they are resolved here once the period is over.
+func (s *State) checkIncidents(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		for _, incident := range s.incidents.list() {
+			available, ok := replicasAvailable(s.kubeClient, incident.Namespace, incident.Workload)
+			switch {
+			case ok && !available:
+				s.incidents.failing(incident.Key(), now)
+			case ok || !incident.Recovering.IsZero():
+				s.recovered(incident.Key(), now)
+			}
+		}
+	}
+}
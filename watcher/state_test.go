@@ -0,0 +1,156 @@
+package watcher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestWatchStateStore tests that the dedup state, incidents and runtime silences saved by one run are loaded by the next, so a
+// failure notified before a restart is not notified again after it and its recovery is still notified.
+func TestWatchStateStore(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "hubbub")
+	if err != nil {
+		t.Fatalf("Error creating a temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testSuite := map[string]struct {
+		storeType    string
+		path         string
+		expectNotify bool
+	}{
+		"Without a store the failure should be notified again": {
+			expectNotify: true,
+		},
+		"With the file store the failure should not be notified again": {
+			storeType: models.StoreFile,
+			path:      filepath.Join(dir, "state.json"),
+		},
+		"With the ConfigMap store the failure should not be notified again": {
+			storeType: models.StoreConfigMap,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Store.Type = testCase.storeType
+		config.Store.Path = testCase.path
+		config.LoadEnvVars()
+
+		kubeClient := fake.NewSimpleClientset()
+		before, err := configuredState(kubeClient, config, &countingHandler{}, silence.NewRegistry())
+		if err != nil {
+			t.Fatalf("Error creating the state %v", err)
+		}
+
+		p := testFailure("api", "api-1", "Error", 1)
+		if before.dedup.check(&p) {
+			before.notified(p)
+		}
+		before.incidents.escalated(p.IncidentKey(), 1)
+		before.silences.Add(models.Silence{Namespace: "payments", End: time.Now().Add(time.Hour)})
+		before.save()
+
+		// the restarted watches
+		after, err := configuredState(kubeClient, config, &countingHandler{}, silence.NewRegistry())
+		if err != nil {
+			t.Fatalf("Error creating the state %v", err)
+		}
+
+		if silences := after.silences.List(); (len(silences) > 0) == testCase.expectNotify {
+			t.Errorf("Expected the silence to be restored : %v, but received %v", !testCase.expectNotify, silences)
+		}
+
+		if open := after.incidents.isOpen(p.IncidentKey()); open == testCase.expectNotify {
+			t.Errorf("Expected the incident to be open after the restart : %v, but received %v", !testCase.expectNotify, open)
+		}
+
+		if incidents := after.incidents.list(); !testCase.expectNotify && incidents[0].Escalated != 1 {
+			t.Errorf("Expected the escalation step of the incident to be restored but received %v", incidents[0].Escalated)
+		}
+
+		p = testFailure("api", "api-2", "Error", 1)
+		if notify := after.dedup.check(&p); notify != testCase.expectNotify {
+			t.Errorf("Expected notify %v after the restart but received %v", testCase.expectNotify, notify)
+		}
+	}
+
+}
+
+// TestStateAcrossRuns tests that the state passed to StartWatcher is kept across its runs, as it is on a config reload, so the
+// second run neither sends the backfill summary again nor notifies a failure that was already reported.
+func TestStateAcrossRuns(t *testing.T) {
+
+	config := &models.Config{Namespace: "hubbub", TimeCheck: 5, Backfill: models.BackfillReport}
+	config.LoadEnvVars()
+
+	pod := testFailedPod("api-1", "1")
+	kubeClient := fake.NewSimpleClientset(pod)
+	handler := &countingHandler{}
+	state := NewState(kubeClient, silence.NewRegistry())
+
+	for run := 1; run <= 2; run++ {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", "Run "+strconv.Itoa(run))
+
+		watches := countActions(kubeClient, "watch")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- StartWatcher(ctx, kubeClient, config, handler, state)
+		}()
+
+		// the backfill is done before the watch is created
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			return countActions(kubeClient, "watch") > watches, nil
+		}); err != nil {
+			t.Fatalf("Expected run %v to watch the pods", run)
+		}
+
+		// a change to the pod that does not change its failure
+		pod.Labels = map[string]string{"run": strconv.Itoa(run)}
+		kubeClient.CoreV1().Pods("hubbub").Update(pod)
+		time.Sleep(100 * time.Millisecond)
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("Error on StartWatcher() %v", err)
+		}
+
+		if handler.count != 1 {
+			t.Errorf("Expected only the summary of the first run to be sent after run %v but received %v notifications", run, handler.count)
+		}
+	}
+
+	if !state.incidents.isOpen("hubbub/Pod/api-1") {
+		t.Errorf("Expected the incident opened by the first run to still be open but received %v", state.incidents.list())
+	}
+
+}
+
+// countActions returns the number of actions with the verb the fake clientset has received.
+func countActions(kubeClient *fake.Clientset, verb string) int {
+
+	count := 0
+	for _, action := range kubeClient.Actions() {
+		if action.GetVerb() == verb {
+			count++
+		}
+	}
+
+	return count
+}
@@ -1,121 +1,262 @@
 package watcher
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"gihutb.com/jxmoore/hubbub/helpers"
 	"gihutb.com/jxmoore/hubbub/models"
 	v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
-// StartWatcher creates the watch.Interface{} that we listen on, kicks off the podWatcher go routine and listens for termination on a
-// channel os.Signal. Its exported as its called
-func StartWatcher(kubeClient *kubernetes.Clientset, config *models.Config, handler models.NotificationHandler) error {
+// podWatcher holds the state used to decide if a pod change in a single namespace should generate a notification.
+type podWatcher struct {
+	config  *models.Config
+	handler models.NotificationHandler
+	state   *State
+	logs    *logFetcher
+	owners  *ownerResolver
+	nodes   *nodeGetter
+}
+
+// StartWatcher creates a pod watch for every namespace returned by config.WatchedNamespaces() (a single cluster wide watch when
+// all namespaces are watched), and a warning event watch if events are enabled. The failures matching one of the silences in the state
+// are not notified. The state is configured for the config and handler and kept by the caller, so the next run picks up where this one
+// stopped. Its exported as its called by BootStrap and only returns if one of the watches could not be started or once ctx is done.
+func StartWatcher(ctx context.Context, kubeClient kubernetes.Interface, config *models.Config, handler models.NotificationHandler, state *State) error {
 
 	fmt.Printf("Starting the watcher...\n")
-	for {
 
-		// Create the watcher, nill listoptions should result in everything in NAMESPACE
-		watcher, err := kubeClient.CoreV1().Pods(config.Namespace).Watch(meta_v1.ListOptions{})
-		if err != nil {
-			return fmt.Errorf("error creating watcher : %v", err)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := state.configure(config, handler); err != nil {
+		return err
+	}
+
+	// the next run configures the state again, so it waits for these to stop before returning
+	var background sync.WaitGroup
+	for _, f := range []func(){
+		func() { state.persist(ctx, storeSaveInterval) },
+		func() { state.checkIncidents(ctx, incidentCheckInterval) },
+		func() { state.checkEscalations(ctx, escalationCheckInterval) },
+		func() { state.sendDigests(ctx, config.TimeLocation) },
+	} {
+		background.Add(1)
+		go func(f func()) {
+			defer background.Done()
+			f()
+		}(f)
+	}
+
+	watches := []*resumableWatch{}
+	for _, namespace := range config.WatchedNamespaces() {
+		watches = append(watches, newPodWatch(kubeClient, namespace, config, handler, state))
+		if config.Events.Enabled {
+			watches = append(watches, newEventWatch(kubeClient, namespace, config, handler, state))
 		}
+	}
 
-		helpers.DebugLog(config.Debug, "Watcher created, starting podWatcher()")
-		podWatcher(watcher, config, handler)
+	errs := make(chan error, len(watches))
+	for _, w := range watches {
+		go func(w *resumableWatch) {
+			errs <- w.run(ctx)
+		}(w)
+	}
 
+	// The watches only return on failure or when ctx is done, cancel stops the rest of them
+	var err error
+	for range watches {
+		if err = <-errs; err != nil {
+			break
+		}
 	}
 
-	return nil
+	cancel()
+	background.Wait()
+
+	// the buffered groups are sent and the state saved once more now that the watches have stopped, so nothing
+	// sent while stopping is lost
+	state.group.flush()
+	state.save()
+
+	return err
 }
 
-// podWatcher runs on a go routine that loops until we receive a signal on sigterm.
-// It uses the resultchan in the watch interface to listen for events from Kubernetes and parses pod events, generating notifications for failed pods/containers.
-func podWatcher(watcher watch.Interface, config *models.Config, handler models.NotificationHandler) {
+// newPodWatch returns the resumableWatch for the pods in a namespace, scoped to the label and field selectors in the config.
+// Modified pods are checked for failures, as are the pods listed after the resourceVersion expires. If a backfill mode is set the pods
+// in the initial list are backfilled.
+func newPodWatch(kubeClient kubernetes.Interface, namespace string, config *models.Config, handler models.NotificationHandler, state *State) *resumableWatch {
+
+	pw := &podWatcher{
+		config:  config,
+		handler: handler,
+		state:   state,
+		logs:    newLogFetcher(kubeClient, config),
+		owners:  newOwnerResolver(kubeClient, config.Debug),
+		nodes:   newNodeGetter(kubeClient, config.Debug),
+	}
+	pods := kubeClient.CoreV1().Pods(namespace)
+
+	rw := &resumableWatch{
+		kind:      "pods",
+		namespace: namespace,
+		debug:     config.Debug,
+		options: meta_v1.ListOptions{
+			LabelSelector: config.Labels,
+			FieldSelector: config.Fields,
+		},
+		list: func(options meta_v1.ListOptions) (string, []runtime.Object, error) {
+			list, err := pods.List(options)
+			if err != nil {
+				return "", nil, err
+			}
+			items := make([]runtime.Object, len(list.Items))
+			for i := range list.Items {
+				items[i] = &list.Items[i]
+			}
+			return list.ResourceVersion, items, nil
+		},
+		watch: pods.Watch,
+		handle: func(eventType watch.EventType, obj runtime.Object) {
+			// Modified is the only type we care about here
+			// deletions and creation will be too noisey due to deployments
+			if pod, ok := obj.(*v1.Pod); ok && eventType == watch.Modified {
+				pw.checkPod(pod)
+			}
+		},
+		resync: func(obj runtime.Object) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				pw.checkPod(pod)
+			}
+		},
+	}
 
-	lastNotification := models.PodStatusInformation{}
+	if config.Backfill != "" {
+		rw.backfill = pw.backfill
+	}
 
-	for {
+	return rw
+}
 
-		select {
-		case e, open := <-watcher.ResultChan():
+// checkPod generates a notification for the pod if it has failed and the failure is new, when grouping is enabled the failure
+// is added to its group instead. Every failure is counted for the digest. A pod that has not failed is checked for the recovery of its workload.
+func (w *podWatcher) checkPod(pod *v1.Pod) {
 
-			if !open || e.Object == nil {
-				helpers.DebugLog(config.Debug, "The channel has been closed, attempting to recreate the watcher")
-				return
-			}
+	podInformation, ok := w.load(pod)
+	if !ok {
+		return
+	}
 
-			// Skip if not pod
-			pod, ok := e.Object.(*v1.Pod)
-			if !ok {
-				continue
-			}
+	if !podInformation.Failed() {
+		w.checkRecovered(pod)
+		return
+	}
 
-			// ignore self
-			if config.Self != "" {
-				if strings.Contains(strings.ToLower(pod.Name), strings.ToLower(config.Self)) {
-					helpers.DebugLog(config.Debug, "Detected and excluding a change, the pod is : "+pod.Name+". Skiping as it is matching the self attribute : '"+config.Self+"'.")
-					continue
-				}
-			}
+	w.state.record(podInformation)
+	w.state.incidents.failing(podInformation.IncidentKey(), time.Now())
 
-			switch e.Type {
+	if ok := w.state.dedup.check(&podInformation); ok {
 
-			// Modified is the only type we care about here
-			// deletions and creation will be too noisey due to deployments
-			case watch.Modified:
+		if w.state.silenced(podInformation) {
+			return
+		}
+
+		helpers.DebugLog(w.config.Debug, "Pod : "+pod.Name+", is new. Generating a notification.")
+		w.logs.attach(&podInformation)
+		w.nodes.attach(&podInformation)
+
+		if w.state.group != nil {
+			w.state.group.add(podInformation)
+			return
+		}
+
+		if err := helpers.NewNotification(w.handler, podInformation); err != nil {
+			fmt.Println(err.Error()) // non termintating
+		} else {
+			w.state.notified(podInformation)
+		}
+	}
+}
 
-				helpers.DebugLog(config.Debug, "New pod change detected : "+pod.Name+"\nMessage :"+pod.Status.Message+"\nReason : "+pod.Status.Reason, pod.Status.ContainerStatuses)
+// checkRecovered starts the recovery of the workload that owns the pod once the pod is Ready, its incident is resolved once it has
+// stayed healthy for the recovery period. If the workload has replicas it is only healthy once every replica is available.
+func (w *podWatcher) checkRecovered(pod *v1.Pod) {
 
-				if pod.DeletionTimestamp != nil {
-					helpers.DebugLog(config.Debug, "Skipping pod : "+pod.Name+" as it was marked for deletion.")
-					continue
-				}
+	if w.state.incidents.empty() || !podRecovered(pod) {
+		return
+	}
+
+	p := models.PodStatusInformation{Namespace: pod.Namespace, PodName: pod.Name}
+	owner := w.owners.resolve(pod)
+	p.OwnerKind, p.OwnerName = owner.kind, owner.name
 
-				podInformation := models.PodStatusInformation{}
+	key := p.IncidentKey()
+	if !w.state.incidents.isOpen(key) {
+		return
+	}
 
-				switch pod.Status.Phase {
+	if available, ok := replicasAvailable(w.state.kubeClient, p.Namespace, p.Workload()); ok && !available {
+		helpers.DebugLog(w.config.Debug, "Pod : "+pod.Name+" is Ready but "+p.Workload()+" is not available yet")
+		return
+	}
 
-				// Failing to start, encountered error etc....
-				case v1.PodFailed:
+	w.state.recovered(key, time.Now())
+}
 
-					podInformation.Load(pod)
-					podInformation.ConvertTime(config.TimeLocation)
+// load loads the failures of the pod along with its owner and annotations and classifies their severity. ok is false if the pod should
+// not generate notifications, because of its namespace, name, annotations, severity or because it is being deleted.
+func (w *podWatcher) load(pod *v1.Pod) (podInformation models.PodStatusInformation, ok bool) {
 
-					if ok := podInformation.IsNew(lastNotification, config.TimeCheck); ok {
+	// ignore namespaces that are excluded or not included when watching the whole cluster
+	if !w.config.NamespaceMatch(pod.Namespace) {
+		return podInformation, false
+	}
 
-						helpers.DebugLog(config.Debug, "Pod : "+pod.Name+", is new. Generating a notification.")
+	// ignore self
+	if w.config.Self != "" {
+		if strings.Contains(strings.ToLower(pod.Name), strings.ToLower(w.config.Self)) {
+			helpers.DebugLog(w.config.Debug, "Detected and excluding a change, the pod is : "+pod.Name+". Skiping as it is matching the self attribute : '"+w.config.Self+"'.")
+			return podInformation, false
+		}
+	}
 
-						if err := helpers.NewNotification(handler, podInformation); err != nil {
-							fmt.Println(err.Error()) // non termintating
-						} else {
-							lastNotification = podInformation
-						}
+	helpers.DebugLog(w.config.Debug, "New pod change detected : "+pod.Name+"\nMessage :"+pod.Status.Message+"\nReason : "+pod.Status.Reason, pod.Status.ContainerStatuses)
 
-					}
+	if pod.DeletionTimestamp != nil {
+		helpers.DebugLog(w.config.Debug, "Skipping pod : "+pod.Name+" as it was marked for deletion.")
+		return podInformation, false
+	}
 
-				// Other issues
-				default:
+	// Load() handles both failed pods (failing to start, encountered error etc....) and other issues
+	podInformation.Load(pod)
+	podInformation.ConvertTime(w.config.TimeLocation)
 
-					podInformation.Load(pod)
-					podInformation.ConvertTime(config.TimeLocation)
+	if len(podInformation.Failures) > 0 {
 
-					if ok := podInformation.IsNew(lastNotification, config.TimeCheck); ok {
+		owner := w.owners.resolve(pod)
+		annotations := models.MergeAnnotations(owner.annotations, pod.Annotations)
+		if models.Ignored(annotations) {
+			helpers.DebugLog(w.config.Debug, "Skipping pod : "+pod.Name+" as it is annotated with "+models.AnnotationIgnore)
+			return podInformation, false
+		}
 
-						helpers.DebugLog(config.Debug, "Pod : "+pod.Name+", is new. Generating a notification.")
+		podInformation.OwnerKind, podInformation.OwnerName = owner.kind, owner.name
+		podInformation.LoadAnnotations(annotations)
+		podInformation.Classify(w.config)
 
-						if err := helpers.NewNotification(handler, podInformation); err != nil {
-							fmt.Println(err.Error()) // non termintating
-						} else {
-							lastNotification = podInformation
-						}
-					}
-				}
-			}
+		if !w.config.SeverityNotified(podInformation.Severity) {
+			helpers.DebugLog(w.config.Debug, "Skipping pod : "+pod.Name+" as its severity '"+podInformation.Severity+"' is below the minimum severity")
+			return podInformation, false
 		}
 	}
+
+	return podInformation, true
 }
@@ -0,0 +1,492 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gihutb.com/jxmoore/hubbub/models"
+	"gihutb.com/jxmoore/hubbub/silence"
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// countingHandler is a NotificationHandler that counts the notifications it receives.
+type countingHandler struct {
+	count int
+}
+
+func (c *countingHandler) Init(config *models.Config) error { return nil }
+
+func (c *countingHandler) Notify(details models.NotificationDetails) error {
+	c.count++
+	return nil
+}
+
+// configuredState returns a State configured for the config, as StartWatcher does.
+func configuredState(kubeClient kubernetes.Interface, config *models.Config, handler models.NotificationHandler, silences *silence.Registry) (*State, error) {
+
+	state := NewState(kubeClient, silences)
+	return state, state.configure(config, handler)
+}
+
+// testState returns the State for the config, the state is only kept in memory.
+func testState(config *models.Config, handler models.NotificationHandler) *State {
+
+	state, _ := configuredState(nil, config, handler, nil)
+	return state
+}
+
+// testFailedPod returns a pod with a single container that terminated with exit code 1.
+func testFailedPod(name, resourceVersion string) *v1.Pod {
+
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "hubbub", ResourceVersion: resourceVersion},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app", Image: "hubbub:1"}}},
+		Status: v1.PodStatus{
+			Phase: v1.PodFailed,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				Image: "hubbub:1",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					ExitCode:   1,
+					Reason:     "Error",
+					FinishedAt: meta_v1.Now(),
+				}},
+			}},
+		},
+	}
+}
+
+// testWarningEvent returns a Warning event about the pod with the given reason and count.
+func testWarningEvent(pod, reason string, count int32) *v1.Event {
+
+	return &v1.Event{
+		ObjectMeta:     meta_v1.ObjectMeta{Name: pod + "." + reason, Namespace: "hubbub", ResourceVersion: "1"},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "hubbub"},
+		Type:           v1.EventTypeWarning,
+		Reason:         reason,
+		Message:        reason + " for " + pod,
+		Count:          count,
+		FirstTimestamp: meta_v1.Now(),
+		LastTimestamp:  meta_v1.Now(),
+	}
+}
+
+// testLabeledPod returns the failed pod from testFailedPod() with the label key=value.
+func testLabeledPod(name, key, value string) *v1.Pod {
+
+	pod := testFailedPod(name, "1")
+	pod.Labels = map[string]string{key: value}
+	return pod
+}
+
+// testWatch returns a stopped watch.Interface{} that delivers the events before its channel closes.
+func testWatch(events []watch.Event) watch.Interface {
+
+	w := watch.NewFakeWithChanSize(len(events), false)
+	for _, e := range events {
+		w.Action(e.Type, e.Object)
+	}
+	w.Stop()
+
+	return w
+}
+
+// TestPodWatch tests consuming a pod watch created by newPodWatch(), verifying the resourceVersion is tracked, only Modified events generate
+// notifications and an expired resourceVersion is returned as an error.
+func TestPodWatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		events                  []watch.Event
+		expectedResourceVersion string
+		expectedNotifications   int
+		expectExpired           bool
+	}{
+		"A modified failed pod should generate a notification": {
+			events: []watch.Event{
+				{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+			},
+			expectedResourceVersion: "12",
+			expectedNotifications:   1,
+		},
+		"Added pods and bookmarks should only update the resourceVersion": {
+			events: []watch.Event{
+				{Type: watch.Added, Object: testFailedPod("api-1", "12")},
+				{Type: watch.Bookmark, Object: &v1.Pod{ObjectMeta: meta_v1.ObjectMeta{ResourceVersion: "15"}}},
+			},
+			expectedResourceVersion: "15",
+		},
+		"A 410 should end the watch with an expired error": {
+			events: []watch.Event{
+				{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+				{Type: watch.Error, Object: &errors.NewResourceExpired("too old resource version").ErrStatus},
+				{Type: watch.Modified, Object: testFailedPod("api-2", "20")},
+			},
+			expectedResourceVersion: "12",
+			expectedNotifications:   1,
+			expectExpired:           true,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.LoadEnvVars()
+		w := newPodWatch(fake.NewSimpleClientset(), "hubbub", config, handler, testState(config, handler))
+
+		received, err := w.consume(context.Background(), testWatch(testCase.events))
+		if !received {
+			t.Errorf("Expected consume() to report the events as received")
+		}
+
+		if isExpired(err) != testCase.expectExpired {
+			t.Errorf("Expected an expired error : %v, but received %v", testCase.expectExpired, err)
+		}
+
+		if w.resourceVersion != testCase.expectedResourceVersion {
+			t.Errorf("Expected the resourceVersion %v but received %v", testCase.expectedResourceVersion, w.resourceVersion)
+		}
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+	}
+
+}
+
+// TestSilencedPodWatch tests that a failed pod matching a silence does not generate a notification or open an incident.
+func TestSilencedPodWatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		silence               models.Silence
+		expectedNotifications int
+	}{
+		"A pod in a silenced namespace should not generate a notification": {
+			silence: models.Silence{ID: "maintenance", Namespace: "hubbub", End: time.Now().Add(time.Hour)},
+		},
+		"A pod that does not match the silence should generate a notification": {
+			silence:               models.Silence{ID: "maintenance", Reason: "OOMKilled", End: time.Now().Add(time.Hour)},
+			expectedNotifications: 1,
+		},
+		"A silence that has ended should not apply": {
+			silence:               models.Silence{ID: "maintenance", Start: time.Now().Add(-time.Hour), End: time.Now().Add(-time.Minute)},
+			expectedNotifications: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.LoadEnvVars()
+
+		silences := silence.NewRegistry()
+		silences.SetConfigured([]models.Silence{testCase.silence})
+		state, _ := configuredState(nil, config, handler, silences)
+		w := newPodWatch(fake.NewSimpleClientset(), "hubbub", config, handler, state)
+
+		w.consume(context.Background(), testWatch([]watch.Event{
+			{Type: watch.Modified, Object: testFailedPod("api-1", "12")},
+			{Type: watch.Modified, Object: testFailedPod("api-1", "13")},
+		}))
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+
+		if open := !state.incidents.empty(); open != (testCase.expectedNotifications > 0) {
+			t.Errorf("Expected an open incident : %v, but received %v", testCase.expectedNotifications > 0, open)
+		}
+	}
+
+}
+
+// TestLabeledEventWatch tests that the silences and severity rules with a label selector are matched on the labels of the pod
+// a Warning event is about.
+func TestLabeledEventWatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		silence               models.Silence
+		rules                 []models.SeverityRule
+		expectedNotifications int
+	}{
+		"An event for a pod matching a label silence should not generate a notification": {
+			silence: models.Silence{ID: "maintenance", Labels: "tier=backend", End: time.Now().Add(time.Hour)},
+		},
+		"An event for a pod that does not match the label silence should generate a notification": {
+			silence:               models.Silence{ID: "maintenance", Labels: "tier=frontend", End: time.Now().Add(time.Hour)},
+			expectedNotifications: 1,
+		},
+		"An event for a pod matching a label severity rule below the minimum should be skipped": {
+			rules: []models.SeverityRule{{Severity: models.SeverityInfo, Labels: "tier=backend"}},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Severity.Rules = testCase.rules
+		config.Severity.Minimum = models.SeverityWarning
+		config.LoadEnvVars()
+
+		silences := silence.NewRegistry()
+		if testCase.silence.ID != "" {
+			silences.SetConfigured([]models.Silence{testCase.silence})
+		}
+		state, _ := configuredState(nil, config, handler, silences)
+		w := newEventWatch(fake.NewSimpleClientset(testLabeledPod("api-1", "tier", "backend")), "hubbub", config, handler, state)
+
+		w.consume(context.Background(), testWatch([]watch.Event{
+			{Type: watch.Added, Object: testWarningEvent("api-1", "FailedMount", 1)},
+		}))
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+	}
+
+}
+
+// TestEventWatch tests consuming an event watch created by newEventWatch(), verifying the reason filter, the label selector and
+// that repeats of the same event only generate a single notification.
+func TestEventWatch(t *testing.T) {
+
+	testSuite := map[string]struct {
+		reasons []string
+		// selector is the label selector in the config, pods are the pods the events are about
+		selector              string
+		pods                  []runtime.Object
+		events                []watch.Event
+		expectedNotifications int
+	}{
+		"A FailedMount event should generate a notification": {
+			events: []watch.Event{
+				{Type: watch.Added, Object: testWarningEvent("api-1", "FailedMount", 1)},
+			},
+			expectedNotifications: 1,
+		},
+		"A repeated event should only generate one notification": {
+			events: []watch.Event{
+				{Type: watch.Added, Object: testWarningEvent("api-1", "FailedScheduling", 1)},
+				{Type: watch.Modified, Object: testWarningEvent("api-1", "FailedScheduling", 2)},
+				{Type: watch.Modified, Object: testWarningEvent("api-1", "FailedScheduling", 3)},
+			},
+			expectedNotifications: 1,
+		},
+		"Events with reasons that are not in the config should be skipped": {
+			reasons: []string{"FailedMount"},
+			events: []watch.Event{
+				{Type: watch.Added, Object: testWarningEvent("api-1", "FailedScheduling", 1)},
+				{Type: watch.Added, Object: testWarningEvent("api-2", "FailedMount", 1)},
+			},
+			expectedNotifications: 1,
+		},
+		"Events for pods outside of the label selector should be skipped": {
+			selector: "tier=backend",
+			pods:     []runtime.Object{testLabeledPod("api-1", "tier", "backend"), testLabeledPod("web-1", "tier", "frontend")},
+			events: []watch.Event{
+				{Type: watch.Added, Object: testWarningEvent("api-1", "FailedMount", 1)},
+				{Type: watch.Added, Object: testWarningEvent("web-1", "FailedMount", 1)},
+				{Type: watch.Added, Object: testWarningEvent("deleted-1", "FailedMount", 1)},
+			},
+			expectedNotifications: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5, Labels: testCase.selector}
+		config.Events.Reasons = testCase.reasons
+		config.LoadEnvVars()
+		w := newEventWatch(fake.NewSimpleClientset(testCase.pods...), "hubbub", config, handler, testState(config, handler))
+
+		if _, err := w.consume(context.Background(), testWatch(testCase.events)); err != nil {
+			t.Errorf("Error on consume() %v", err)
+		}
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+	}
+
+}
+
+// TestEventWatchGrouping tests that with grouping enabled a Warning event is added to its group rather than sent straight away.
+func TestEventWatchGrouping(t *testing.T) {
+
+	handler := &countingHandler{}
+	config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+	config.Grouping.Window = 60
+	config.LoadEnvVars()
+	state := testState(config, handler)
+	w := newEventWatch(fake.NewSimpleClientset(), "hubbub", config, handler, state)
+
+	if _, err := w.consume(context.Background(), testWatch([]watch.Event{
+		{Type: watch.Added, Object: testWarningEvent("api-1", "FailedMount", 1)},
+	})); err != nil {
+		t.Errorf("Error on consume() %v", err)
+	}
+
+	if handler.count != 0 {
+		t.Errorf("Expected the event to be buffered in its group but received %v notifications", handler.count)
+	}
+
+	state.group.flush()
+	if handler.count != 1 {
+		t.Errorf("Expected the event to be sent once its group is flushed but received %v notifications", handler.count)
+	}
+
+}
+
+// TestIsExpired tests isExpired() against the errors returned by the API server.
+func TestIsExpired(t *testing.T) {
+
+	testSuite := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"A resource expired error is expired": {
+			err:      errors.NewResourceExpired("too old resource version"),
+			expected: true,
+		},
+		"A gone error is expired": {
+			err:      errors.NewGone("gone"),
+			expected: true,
+		},
+		"A forbidden error is not expired": {
+			err:      errors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", nil),
+			expected: false,
+		},
+		"nil is not expired": {
+			expected: false,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		if ok := isExpired(testCase.err); ok != testCase.expected {
+			t.Errorf("expected %v but received %v", testCase.expected, ok)
+		}
+	}
+
+}
+
+// TestPodAnnotations tests that the hubbub.io annotations on a pod and its owner are honored by checkPod().
+func TestPodAnnotations(t *testing.T) {
+
+	testSuite := map[string]struct {
+		podAnnotations        map[string]string
+		ownerAnnotations      map[string]string
+		expectedNotifications int
+	}{
+		"A pod without annotations should generate a notification": {
+			expectedNotifications: 1,
+		},
+		"A pod annotated with ignore should be skipped": {
+			podAnnotations: map[string]string{"hubbub.io/ignore": "true"},
+		},
+		"A pod whose owner is annotated with ignore should be skipped": {
+			ownerAnnotations: map[string]string{"hubbub.io/ignore": "true"},
+		},
+		"The pod annotation should take precedence over its owner": {
+			podAnnotations:        map[string]string{"hubbub.io/ignore": "false"},
+			ownerAnnotations:      map[string]string{"hubbub.io/ignore": "true"},
+			expectedNotifications: 1,
+		},
+		"A pod that exited with an ignored exit code should be skipped": {
+			ownerAnnotations: map[string]string{"hubbub.io/ignore-exit-codes": "143, 1"},
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		pod := testFailedPod("api-1", "12")
+		pod.Annotations = testCase.podAnnotations
+		controller := true
+		pod.OwnerReferences = []meta_v1.OwnerReference{{Kind: "StatefulSet", Name: "api", Controller: &controller}}
+		statefulSet := &apps_v1.StatefulSet{ObjectMeta: meta_v1.ObjectMeta{Name: "api", Namespace: "hubbub", Annotations: testCase.ownerAnnotations}}
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.LoadEnvVars()
+		w := newPodWatch(fake.NewSimpleClientset(statefulSet), "hubbub", config, handler, testState(config, handler))
+		w.resync(pod)
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+	}
+
+}
+
+// TestPodSeverity tests that the pods classified below the minimum severity do not generate notifications.
+func TestPodSeverity(t *testing.T) {
+
+	testSuite := map[string]struct {
+		minimum               string
+		podAnnotations        map[string]string
+		expectedNotifications int
+	}{
+		"A pod should be notified without a minimum severity": {
+			expectedNotifications: 1,
+		},
+		"A pod below the minimum severity should be skipped": {
+			minimum: models.SeverityWarning,
+		},
+		"The severity annotation should take precedence over the rules": {
+			minimum:               models.SeverityWarning,
+			podAnnotations:        map[string]string{"hubbub.io/severity": "critical"},
+			expectedNotifications: 1,
+		},
+	}
+
+	for testName, testCase := range testSuite {
+
+		t.Logf("\n\nRunning TestCase %v...\n\n", testName)
+
+		pod := testFailedPod("api-1", "12")
+		pod.Annotations = testCase.podAnnotations
+
+		handler := &countingHandler{}
+		config := &models.Config{Namespace: "hubbub", TimeCheck: 5}
+		config.Severity.Minimum = testCase.minimum
+		config.Severity.Rules = []models.SeverityRule{{Severity: models.SeverityInfo, ExitCodes: []int{1}}}
+		config.LoadEnvVars()
+
+		state := testState(config, handler)
+		w := newPodWatch(fake.NewSimpleClientset(), "hubbub", config, handler, state)
+		w.resync(pod)
+
+		if handler.count != testCase.expectedNotifications {
+			t.Errorf("Expected %v notifications but received %v", testCase.expectedNotifications, handler.count)
+		}
+
+		if open := !state.incidents.empty(); open != (testCase.expectedNotifications > 0) {
+			t.Errorf("Expected an open incident : %v, but received %v", testCase.expectedNotifications > 0, open)
+		}
+	}
+
+}